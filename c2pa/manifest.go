@@ -0,0 +1,309 @@
+// Package c2pa exports PhotoGnark photographs as C2PA-style manifests, so tools that understand content
+// credentials can display what happened to an image, and imports them back into photographs.
+//
+// A manifest is not signed with COSE as C2PA requires: its trust comes from the PCD proof and the signatures
+// it carries, which photoproof.Verify() checks once the manifest is imported.
+package c2pa
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+/*-----------------------------------------------------Manifest--------------------------------------------------*/
+
+const Claim_Generator = "PhotoGnark"
+
+// Assertion labels. PhotoGnark specific assertions use the "photognark." prefix, as C2PA custom assertions do.
+const (
+	Label_Actions    = "c2pa.actions"          // Capture, then every transformation recorded in the provenance
+	Label_Provenance = "photognark.provenance" // The provenance report, original & remaining bound per transformation
+	Label_Signers    = "photognark.signers"    // Camera & editor keys and signatures
+	Label_ZKProof    = "photognark.zkproof"    // PCD proof of the whole edit history
+	Label_Metadata   = "photognark.metadata"   // Capture metadata, hashed with the pixels
+)
+
+// A C2PA-style manifest of a PhotoGnark photograph
+type Manifest struct {
+	Claim_Generator string      `json:"claim_generator"`
+	Format          string      `json:"format"`
+	Instance_Id     string      `json:"instance_id"` // Hex encoded image hash (image.ImageHash)
+	Assertions      []Assertion `json:"assertions"`
+}
+
+// A labelled assertion. Data is one of the *_Assertion types below, depending on Label.
+type Assertion struct {
+	Label string `json:"label"`
+	Data  any    `json:"data"`
+}
+
+// C2PA actions assertion
+type Actions_Assertion struct {
+	Actions []Action `json:"actions"`
+}
+
+// A C2PA action
+type Action struct {
+	Action         string            `json:"action"` // "c2pa.created" for the capture, "c2pa.edited" for a transformation
+	Software_Agent string            `json:"software_agent,omitempty"`
+	When           string            `json:"when,omitempty"` // RFC 3339 capture time of "c2pa.created"
+	Parameters     *Action_Parameter `json:"parameters,omitempty"`
+}
+
+// The transformation behind a "c2pa.edited" action and how much of its budget the edits consumed
+type Action_Parameter struct {
+	Transformation string `json:"transformation"`
+	Id             uint64 `json:"id"`
+	Consumed       uint64 `json:"consumed"`
+}
+
+// Keys and signatures. Public keys are the compressed EdDSA BN254 keys, as in photoproof.Bundle.
+type Signers_Assertion struct {
+	Original_PublicKey []byte `json:"original_public_key"` // Camera (Admin)
+	Original_Signature []byte `json:"original_signature"`
+	PublicKey          []byte `json:"public_key"` // Last editor
+	Signature          []byte `json:"signature"`
+}
+
+// The PCD proof, and what is needed to verify it
+type ZKProof_Assertion struct {
+	System                   string                   `json:"system"` // "groth16"
+	Curve                    string                   `json:"curve"`  // "bn254"
+	Mode                     photoproof.Mode          `json:"mode"`
+	VerifyingKey_Fingerprint string                   `json:"verifying_key_fingerprint"`
+	Cameras                  int                      `json:"cameras,omitempty"`          // Number of authorised cameras the proof was made against
+	Revocation_Epoch         uint64                   `json:"revocation_epoch,omitempty"` // Epoch of the revocation list the proof was made against
+	Editors                  int                      `json:"editors,omitempty"`          // Number of authorised editors the proof was made against
+	Area                     *image.Area              `json:"area,omitempty"`             // With photoproof.Mode_Region, the only pixels proven
+	Input_Commitment         []byte                   `json:"input_commitment,omitempty"` // Commitment to the image the last edit was applied to
+	Previous                 []photoproof.Bundle_Edit `json:"previous,omitempty"`         // Proofs of the edits before the last one
+	Original_Hash            []byte                   `json:"original_hash"`
+	Capture                  image.Capture            `json:"capture"`
+	Timestamp                *image.Timestamp_Token   `json:"timestamp,omitempty"`
+	PCD_Proof                []byte                   `json:"pcd_proof"`
+}
+
+/*------------------------------------------------------Export---------------------------------------------------*/
+
+// Returns the manifest of a proven photograph. Its provenance is decoded with the policy of its verifier keys.
+func Export(photo photoproof.Photograph) (Manifest, error) {
+	bundle, err := photo.Bundle()
+	if err != nil {
+		return Manifest{}, err
+	}
+	report, err := photo.Report()
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	created := Action{
+		Action:         "c2pa.created",
+		Software_Agent: Claim_Generator + " camera",
+		When:           time.Unix(int64(bundle.Capture.Time), 0).UTC().Format(time.RFC3339),
+	}
+	actions := Actions_Assertion{Actions: []Action{created}}
+	for _, entry := range report.Transformations {
+		if entry.Consumed == 0 {
+			continue
+		}
+		actions.Actions = append(actions.Actions, Action{
+			Action:     "c2pa.edited",
+			Parameters: &Action_Parameter{Transformation: entry.Name, Id: entry.Id, Consumed: entry.Consumed},
+		})
+	}
+
+	return Manifest{
+		Claim_Generator: Claim_Generator,
+		Format:          "image/png",
+		Instance_Id:     hex.EncodeToString(image.ImageHash(photo.Z.Img)),
+		Assertions: []Assertion{
+			{Label: Label_Actions, Data: actions},
+			{Label: Label_Provenance, Data: report},
+			{Label: Label_Metadata, Data: photo.Z.Img.Metadata},
+			{Label: Label_Signers, Data: Signers_Assertion{
+				Original_PublicKey: bundle.Original_PublicKey,
+				Original_Signature: bundle.Original_Signature,
+				PublicKey:          bundle.PublicKey,
+				Signature:          bundle.Signature,
+			}},
+			{Label: Label_ZKProof, Data: ZKProof_Assertion{
+				System:                   "groth16",
+				Curve:                    "bn254",
+				Mode:                     photo.VerifyingKeys.Mode,
+				VerifyingKey_Fingerprint: bundle.VerifyingKey_Fingerprint,
+				Cameras:                  bundle.Cameras,
+				Revocation_Epoch:         bundle.Revocation_Epoch,
+				Editors:                  bundle.Editors,
+				Area:                     bundle.Area,
+				Input_Commitment:         bundle.Input_Commitment,
+				Previous:                 bundle.Previous,
+				Original_Hash:            bundle.Original_Hash,
+				Capture:                  bundle.Capture,
+				Timestamp:                bundle.Timestamp,
+				PCD_Proof:                bundle.PCD_Proof,
+			}},
+		},
+	}, nil
+}
+
+/*------------------------------------------------------Import---------------------------------------------------*/
+
+// Returns the photograph described by the manifest, for the image's pixels (e.g. decoded with image.FromPNG())
+// and the Admin's keys. The provenance is restored from the manifest, and the resulting image must match the
+// manifest's instance id. The photograph still has to be verified with photoproof.Verify().
+func Import(manifest Manifest, pixels image.Image, pk photoproof.ProverKeys, vk photoproof.VerifierKeys) (photoproof.Photograph, error) {
+	var (
+		report   *photoproof.Report
+		signers  *Signers_Assertion
+		zk       *ZKProof_Assertion
+		metadata image.Metadata // Absent if the image has none
+	)
+	for _, assertion := range manifest.Assertions {
+		switch data := assertion.Data.(type) {
+		case photoproof.Report:
+			report = &data
+		case Signers_Assertion:
+			signers = &data
+		case ZKProof_Assertion:
+			zk = &data
+		case image.Metadata:
+			metadata = data
+		}
+	}
+	if report == nil || signers == nil || zk == nil {
+		return photoproof.Photograph{}, fmt.Errorf("%w: manifest lacks the %s, %s or %s assertion", photoproof.ErrNoProof, Label_Provenance, Label_Signers, Label_ZKProof)
+	}
+
+	// Restore the provenance: every transformation in its slot, with its remaining bound
+	provenance := [image.P]image.Provenance{}
+	for _, entry := range report.Transformations {
+		if entry.Slot < 0 || entry.Slot >= int(image.P) {
+			return photoproof.Photograph{}, fmt.Errorf("%w: provenance slot %d", photoproof.ErrDecode, entry.Slot)
+		}
+		provenance[entry.Slot] = image.Provenance{Tr_Name: entry.Id, Tr_Bound: entry.Remaining_Bound}
+	}
+	img := pixels
+	img.Metadata = metadata
+	img.SetProvenance(provenance)
+
+	if hex.EncodeToString(image.ImageHash(img)) != manifest.Instance_Id {
+		return photoproof.Photograph{}, fmt.Errorf("%w: image does not match the manifest's instance id", photoproof.ErrImageMismatch)
+	}
+
+	bundle := photoproof.Bundle{
+		Image:                    img,
+		Original_PublicKey:       signers.Original_PublicKey,
+		Original_Signature:       signers.Original_Signature,
+		Original_Hash:            zk.Original_Hash,
+		Capture:                  zk.Capture,
+		Timestamp:                zk.Timestamp,
+		PCD_Proof:                zk.PCD_Proof,
+		Signature:                signers.Signature,
+		PublicKey:                signers.PublicKey,
+		Cameras:                  zk.Cameras,
+		Revocation_Epoch:         zk.Revocation_Epoch,
+		Editors:                  zk.Editors,
+		Area:                     zk.Area,
+		Input_Commitment:         zk.Input_Commitment,
+		Previous:                 zk.Previous,
+		VerifyingKey_Fingerprint: zk.VerifyingKey_Fingerprint,
+	}
+
+	return bundle.Photograph(pk, vk)
+}
+
+/*-----------------------------------------------------Encoding--------------------------------------------------*/
+
+// Encode the manifest as indented JSON
+func (manifest Manifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(manifest, "", "  ")
+}
+
+// Encode the manifest as CBOR, the encoding C2PA uses for manifests embedded in assets
+func (manifest Manifest) CBOR() ([]byte, error) {
+	return cbor.Marshal(manifest)
+}
+
+// Decode a manifest encoded with Manifest.JSON()
+func ParseJSON(data []byte) (Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, photoproof.Wrap(photoproof.ErrDecode, err)
+	}
+	return manifest, nil
+}
+
+// Decode a manifest encoded with Manifest.CBOR()
+func ParseCBOR(data []byte) (Manifest, error) {
+	var manifest Manifest
+	if err := cbor.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, photoproof.Wrap(photoproof.ErrDecode, err)
+	}
+	return manifest, nil
+}
+
+// Assertion data is decoded in the type of its label
+func (assertion *Assertion) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Label string          `json:"label"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return assertion.decode(raw.Label, raw.Data, json.Unmarshal)
+}
+
+// Assertion data is decoded in the type of its label
+func (assertion *Assertion) UnmarshalCBOR(data []byte) error {
+	var raw struct {
+		Label string          `json:"label"`
+		Data  cbor.RawMessage `json:"data"`
+	}
+	if err := cbor.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return assertion.decode(raw.Label, raw.Data, cbor.Unmarshal)
+}
+
+func (assertion *Assertion) decode(label string, data []byte, unmarshal func([]byte, any) error) error {
+	assertion.Label = label
+
+	var err error
+	switch label {
+	case Label_Actions:
+		var actions Actions_Assertion
+		err = unmarshal(data, &actions)
+		assertion.Data = actions
+	case Label_Provenance:
+		var report photoproof.Report
+		err = unmarshal(data, &report)
+		assertion.Data = report
+	case Label_Signers:
+		var signers Signers_Assertion
+		err = unmarshal(data, &signers)
+		assertion.Data = signers
+	case Label_ZKProof:
+		var zk ZKProof_Assertion
+		err = unmarshal(data, &zk)
+		assertion.Data = zk
+	case Label_Metadata:
+		var metadata image.Metadata
+		err = unmarshal(data, &metadata)
+		assertion.Data = metadata
+	default:
+		// Assertions of other tools are kept as generic values
+		var other any
+		err = unmarshal(data, &other)
+		assertion.Data = other
+	}
+
+	return err
+}
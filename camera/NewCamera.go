@@ -1,47 +1,119 @@
 package camera
 
 import (
-	"fmt"
+	"context"
 
-	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
-	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/constraint"
 	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
 )
 
-func NewCamera() Camera {
-	prover, verifier, admin := Generator(&photoproof.PhotoGnark{})
+// Returns a new camera whose keys are generated for the circuit of the given mode and policy
+func NewCamera(ctx context.Context, mode photoproof.Mode, policy photoproof.Policy) (Camera, error) {
+	prover, verifier, admin, err := Generator(ctx, mode, policy)
+	if err != nil {
+		return Camera{}, err
+	}
+
 	return Camera{
 		Admin:        admin,
 		Photographs:  []photoproof.Photograph{},
 		ProvingKey:   prover,
 		VerifyingKey: verifier,
+	}, nil
+}
+
+// Returns a new camera for the given Admin, e.g. one whose key is held by a signing daemon
+// (see photoproof.NewUserWithSigner()), with keys generated for the circuit of the given mode and policy
+func NewCameraWithAdmin(ctx context.Context, admin photoproof.User, mode photoproof.Mode, policy photoproof.Policy) (Camera, error) {
+	prover, verifier, err := Setup(ctx, admin, mode, policy)
+	if err != nil {
+		return Camera{}, err
 	}
+
+	return Camera{
+		Admin:        admin,
+		Photographs:  []photoproof.Photograph{},
+		ProvingKey:   prover,
+		VerifyingKey: verifier,
+	}, nil
 }
 
-func Generator(circuit *photoproof.PhotoGnark) (photoproof.ProverKeys, photoproof.VerifierKeys, photoproof.User) {
-	fmt.Println("********New Camera********")
+// Compiles the circuit of the given mode with the Admin's policy of permissible transformations
+// (see photoproof.LoadPolicy()) and generates its PCD keys for a new Admin.
+// Compilation and setup report their progress to ctx's Observer and stop when ctx is cancelled.
+func Generator(ctx context.Context, mode photoproof.Mode, policy photoproof.Policy) (photoproof.ProverKeys, photoproof.VerifierKeys, photoproof.User, error) {
 	// Create a new user, including their secret key.
-	user := photoproof.NewUser()
+	user, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, photoproof.User{}, err
+	}
+
+	prover, verifier, err := Setup(ctx, user, mode, policy)
+	if err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, photoproof.User{}, err
+	}
+
+	return prover, verifier, user, nil
+}
+
+// Same as Generator(), for an existing Admin. Only the Admin's public key is used, so its secret key
+// may be held by an external signer.
+func Setup(ctx context.Context, admin photoproof.User, mode photoproof.Mode, policy photoproof.Policy) (photoproof.ProverKeys, photoproof.VerifierKeys, error) {
+	photoproof.Logger().Debug("running generator", "mode", mode, "policy", policy.Name)
+	if err := policy.Validate(); err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, err
+	}
 
 	// Set the security parameter (BN254) and compile a constraint system (aka compliance_predicate)
-	compliance_predicate_id, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+	compliance_predicate_id, err := photoproof.Step(ctx, photoproof.Phase_Compile, func() (constraint.ConstraintSystem, error) {
+		return photoproof.Compile(mode, policy)
+	})
 	if err != nil {
-		fmt.Println("[Generator]: ERROR while compiling constraint system")
-		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, photoproof.User{}
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, err
 	}
 
 	// Generate PCD Keys from the compliance_predicate
-	provingKey, verifyingKey, err := groth16.Setup(compliance_predicate_id)
+	keys, err := photoproof.Step(ctx, photoproof.Phase_Setup, func() (pcdKeys, error) {
+		provingKey, verifyingKey, err := groth16.Setup(compliance_predicate_id)
+		return pcdKeys{provingKey, verifyingKey}, err
+	})
 	if err != nil {
-		fmt.Println("[Generator]: ERROR while generating PCD Keys from the constraint system")
-		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, photoproof.User{}
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, photoproof.Wrap(photoproof.ErrSetup, err)
 	}
 
-	fmt.Println("********[Camera] Generator was successful!********")
+	photoproof.Logger().Info("generator was successful", "mode", mode, "constraints", compliance_predicate_id.GetNbConstraints())
 
-	return photoproof.ProverKeys{ProvingKey: provingKey, Original_PublicKey: user.PublicKey},
-		photoproof.VerifierKeys{VerifyingKey: verifyingKey, Original_PublicKey: user.PublicKey},
-		user
+	// The Admin's camera is the first authorised camera, more are added with Key_Set.Add()
+	cameras, err := photoproof.NewKeySet(admin.PublicKey)
+	if err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, err
+	}
+
+	// No editor is authorised yet, with Mode_Hidden_Editor they are added with Key_Set.Add()
+	editors := photoproof.Key_Set{Keys: [][]byte{}}
+
+	return photoproof.ProverKeys{ProvingKey: keys.provingKey, Original_PublicKey: admin.PublicKey, Mode: mode, Policy: policy, Cameras: cameras, Editors: editors, Compliance_Predicate: compliance_predicate_id},
+		photoproof.VerifierKeys{VerifyingKey: keys.verifyingKey, Original_PublicKey: admin.PublicKey, Mode: mode, Policy: policy, Cameras: cameras, Editors: editors},
+		nil
+}
+
+// Output of groth16.Setup()
+type pcdKeys struct {
+	provingKey   groth16.ProvingKey
+	verifyingKey groth16.VerifyingKey
+}
+
+// Returns a camera for the given Admin, using PCD keys that were generated elsewhere (e.g. by a Ceremony)
+// for the circuit of the given mode and policy
+func NewCameraFromKeys(admin photoproof.User, mode photoproof.Mode, policy photoproof.Policy, provingKey groth16.ProvingKey, verifyingKey groth16.VerifyingKey) Camera {
+	cameras := photoproof.Key_Set{Keys: [][]byte{admin.PublicKey.Bytes()}}
+	editors := photoproof.Key_Set{Keys: [][]byte{}}
+
+	return Camera{
+		Admin:        admin,
+		Photographs:  []photoproof.Photograph{},
+		ProvingKey:   photoproof.ProverKeys{ProvingKey: provingKey, Original_PublicKey: admin.PublicKey, Mode: mode, Policy: policy, Cameras: cameras, Editors: editors},
+		VerifyingKey: photoproof.VerifierKeys{VerifyingKey: verifyingKey, Original_PublicKey: admin.PublicKey, Mode: mode, Policy: policy, Cameras: cameras, Editors: editors},
+	}
 }
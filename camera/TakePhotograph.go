@@ -1,23 +1,80 @@
 package camera
 
 import (
-	"fmt"
+	"context"
+	"time"
 
 	"github.com/drakstik/PhotoGnark_ACDF/image"
 	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
 )
 
 // Returns a new photograph and proves originality.
-func (cam *Camera) TakePhotograph(flag string) (photoproof.Photograph, error) {
-	fmt.Println("********[Camera] Taking photograph********")
+// Proving stops with ctx.Err() when ctx is cancelled.
+func (cam *Camera) TakePhotograph(ctx context.Context, flag string) (photoproof.Photograph, error) {
+	photoproof.Logger().Debug("taking photograph", "flag", flag)
 	img, err := image.NewImage("random") // Get new random image
 	if err != nil {
-		fmt.Println("Error while running NewImage(): " + err.Error())
+		return photoproof.Photograph{}, err
+	}
+
+	return cam.Capture(ctx, img)
+}
+
+// Returns a photograph of img, e.g. decoded with image.FromPNG(), signed by the camera and proven original.
+// Proving stops with ctx.Err() when ctx is cancelled.
+func (cam *Camera) Capture(ctx context.Context, img image.Image) (photoproof.Photograph, error) {
+	photo, err := cam.NewPhotograph(img)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	cam.Photographs = append(cam.Photographs, photo) // Add photo to list of photos in camera
+
+	// Construct new identity transformation and parameters
+	identity_tr := photoproof.Identity_Tr{}
+	params := photoproof.Identity_Tr_Params{}
+
+	// Prove originality of image (Case 1)
+	og_proof, err := cam.Admin.Prove(ctx, photo, photo, identity_tr, params)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	// Set photo's PCD proof
+	photo.Proof.PCD_Proof = og_proof
+	cam.ProvingKey.Record(&photo.Proof)
+
+	return photo, nil
+}
+
+// Returns a photograph of img signed by the camera, without proving originality.
+// The camera sets the image's provenance from the Admin's policy before signing, one entry per enabled transformation.
+// The camera records its serial in the image's metadata, e.g. set from the sensor, GPS and lens by the caller.
+// The original signature covers the metadata, capture time and counter too, see image.CaptureHash().
+// Its PCD proof is nil until it is proven, e.g. by a photoproof.BatchProver run by the camera's Admin.
+func (cam *Camera) NewPhotograph(img image.Image) (photoproof.Photograph, error) {
+	img.SetProvenance(cam.ProvingKey.Policy.Provenance())
+	if cam.Serial != "" {
+		img.Metadata.Device_Serial = cam.Serial
+	}
+	img.Metadata.GPS_Recorded = img.Metadata.GPS != nil
+	if err := img.Metadata.Validate(); err != nil {
+		return photoproof.Photograph{}, photoproof.Wrap(photoproof.ErrInvalidMetadata, err)
 	}
 
-	original_signature, err := cam.Admin.Sign(img) // Sign the image as the camera Admin
+	capture, token, err := cam.capture(img)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	original_hash := image.CaptureHash(img, capture)
+	hash := cam.ProvingKey.Mode.Hash()
+	original_signature, err := cam.Admin.SignCapture(hash, img, capture) // Sign the image & capture as the camera Admin
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+	signature_out, err := cam.Admin.Sign(hash, img) // Case 1's output signature, of the image only
 	if err != nil {
-		fmt.Println("[TakePhotograph()] Error while signing a new image")
 		return photoproof.Photograph{}, err
 	}
 
@@ -27,31 +84,43 @@ func (cam *Camera) TakePhotograph(flag string) (photoproof.Photograph, error) {
 			Img:                img,
 			Original_PublicKey: cam.Admin.PublicKey,
 			Original_Signature: original_signature,
-			Original_Hash:      image.ImageHash(img),
+			Original_Hash:      original_hash,
+			Capture:            capture,
+			Timestamp:          token,
 		},
 		Proof: photoproof.Proof{
 			PCD_Proof: nil, // Case 1: This is an original image
-			Signature: original_signature,
+			Signature: signature_out,
+			PublicKey: cam.Admin.PublicKey,
 		},
 		ProvingKeys:   cam.ProvingKey,
 		VerifyingKeys: cam.VerifyingKey,
 	}
 
-	cam.Photographs = append(cam.Photographs, photo) // Add photo to list of photos in camera
+	return photo, nil
+}
 
-	// Construct new identity transformation and parameters
-	identity_tr := photoproof.Identity_Tr{Flag: 1}
-	params := photoproof.Identity_Tr_Params{}
+// Returns the capture time & counter of img, and the timestamp token if the camera has a Timestamper
+func (cam *Camera) capture(img image.Image) (image.Capture, *image.Timestamp_Token, error) {
+	capture := image.Capture{Time: uint64(time.Now().Unix())}
+	if cam.Use_Counter {
+		cam.Counter++
+		capture.Counter = cam.Counter
+	}
+	if cam.Timestamper == nil {
+		return capture, nil, nil
+	}
 
-	// Prove originality of image (Case 1)
-	og_proof, err := cam.Admin.Prove(photo, photo, identity_tr, params)
+	token, err := cam.Timestamper.Timestamp(image.CaptureDigest(img, capture.Counter))
 	if err != nil {
-		fmt.Println("[TakePhotograph()] Error while proving a new image")
-		return photoproof.Photograph{}, err
+		return image.Capture{}, nil, photoproof.Wrap(photoproof.ErrInvalidTimestamp, err)
 	}
+	capture.Time = token.Time
 
-	// Set photo's PCD proof
-	photo.Proof.PCD_Proof = og_proof
-
-	return photo, err
+	// The token must hold before the camera signs it into the original hash
+	z := image.Z{Img: img, Original_Hash: image.CaptureHash(img, capture), Capture: capture, Timestamp: &token}
+	if err := photoproof.VerifyTimestamp(z); err != nil {
+		return image.Capture{}, nil, err
+	}
+	return capture, &token, nil
 }
@@ -1,6 +1,8 @@
 package camera
 
 import (
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
 	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
 )
 
@@ -10,4 +12,46 @@ type Camera struct {
 	Photographs  []photoproof.Photograph
 	ProvingKey   photoproof.ProverKeys
 	VerifyingKey photoproof.VerifierKeys
+
+	Serial      string      // Device serial recorded in the metadata of every photograph, if set
+	Timestamper Timestamper // Sets the capture time of photographs, the camera's clock does if nil
+	Use_Counter bool        // Number photographs with Counter
+	Counter     uint64      // Capture counter of the last photograph, incremented by every capture with Use_Counter
+}
+
+// A timestamp authority, e.g. a tsa.Authority. It sets the capture time of a capture digest (image.CaptureDigest)
+// and returns its token.
+type Timestamper interface {
+	Timestamp(capture_digest []byte) (image.Timestamp_Token, error)
+}
+
+// Returns another camera under the same setup, signing with its own key. The key is added to the authorised
+// cameras of both cameras' keys, verifiers need the updated VerifyingKey to know its root.
+func (cam *Camera) NewDevice(admin photoproof.User) (Camera, error) {
+	cameras := photoproof.Key_Set{Keys: append([][]byte{}, cam.ProvingKey.Cameras.Keys...)}
+	if err := cameras.Add(admin.PublicKey); err != nil {
+		return Camera{}, err
+	}
+	cam.ProvingKey.Cameras = cameras
+	cam.VerifyingKey.Cameras = cameras
+
+	device := Camera{
+		Admin:        admin,
+		Photographs:  []photoproof.Photograph{},
+		ProvingKey:   cam.ProvingKey,
+		VerifyingKey: cam.VerifyingKey,
+	}
+	return device, nil
+}
+
+// Authorise an editor, for keys generated with photoproof.Mode_Hidden_Editor. The key is added to the authorised
+// editors of the camera's keys, editors and verifiers need the updated keys to know its root.
+func (cam *Camera) AuthoriseEditor(editor signature.PublicKey) error {
+	editors := photoproof.Key_Set{Keys: append([][]byte{}, cam.ProvingKey.Editors.Keys...)}
+	if err := editors.Add(editor); err != nil {
+		return err
+	}
+	cam.ProvingKey.Editors = editors
+	cam.VerifyingKey.Editors = editors
+	return nil
 }
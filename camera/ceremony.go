@@ -0,0 +1,226 @@
+package camera
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	cs "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+/*-------------------------------------------Multi-Party Trusted Setup-------------------------------------------*/
+
+/*
+	Generator() runs a single-party groth16.Setup, which means whoever runs it knows the toxic waste
+	and can forge proofs for any image. A Ceremony replaces Generator() with the two-phase MPC described in
+	https://eprint.iacr.org/2017/1050.pdf, where the keys are sound as long as ONE contributor is honest.
+
+	Phase 1 ("Powers of Tau") is circuit-independent and only depends on the domain size.
+	Phase 2 is specific to the Admin's compliance predicate.
+
+	Every contribution is exchanged as a file, so each participant can run in their own process:
+
+		coordinator:   c.Phase1_Init("phase1_0")
+		contributor i: Phase1_Contribute("phase1_i-1", "phase1_i")
+		coordinator:   c.Phase1_Verify(beacon, "commons", "phase1_1", ..., "phase1_n")
+		coordinator:   c.Phase2_Init("commons", "phase2_0")
+		contributor i: Phase2_Contribute("phase2_i-1", "phase2_i")
+		coordinator:   c.Finalize("commons", beacon, "phase2_1", ..., "phase2_n")
+
+	Anyone can re-run the verification functions on the published files to check the transcript.
+*/
+
+// A trusted setup ceremony for the Admin's compliance predicate
+type Ceremony struct {
+	Compliance_Predicate *cs.R1CS // The compiled circuit the keys are generated for
+	Domain_Size          uint64   // FFT domain size used by Phase 1
+}
+
+// Compile the circuit and return a new Ceremony for it.
+// Every participant compiling the same circuit obtains the same Ceremony.
+func NewCeremony(circuit frontend.Circuit) (*Ceremony, error) {
+	// Set the security parameter (BN254) and compile a constraint system (aka compliance_predicate)
+	compliance_predicate, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		return nil, photoproof.Wrap(photoproof.ErrCompile, err)
+	}
+
+	return &Ceremony{
+		Compliance_Predicate: compliance_predicate.(*cs.R1CS),
+		Domain_Size:          ecc.NextPowerOfTwo(uint64(compliance_predicate.GetNbConstraints())),
+	}, nil
+}
+
+/*-------------------------------------------------Phase 1---------------------------------------------------*/
+
+// Write the empty Phase 1 object that the first contributor starts from
+func (c *Ceremony) Phase1_Init(out_path string) error {
+	return writeTo(out_path, mpcsetup.NewPhase1(c.Domain_Size))
+}
+
+// Read the latest Phase 1 contribution from in_path, add this participant's randomness
+// and write the result to out_path. The randomness is discarded when this function returns.
+func Phase1_Contribute(in_path string, out_path string) error {
+	var p mpcsetup.Phase1
+	if err := readFrom(in_path, &p); err != nil {
+		return err
+	}
+
+	p.Contribute()
+
+	return writeTo(out_path, &p)
+}
+
+// Check that the contribution at next_path was correctly derived from the one at prev_path
+func Phase1_Verify_Contribution(prev_path string, next_path string) error {
+	var prev, next mpcsetup.Phase1
+	if err := readFrom(prev_path, &prev); err != nil {
+		return err
+	}
+	if err := readFrom(next_path, &next); err != nil {
+		return err
+	}
+
+	return photoproof.Wrap(photoproof.ErrCeremony, prev.Verify(&next))
+}
+
+// Verify every Phase 1 contribution in order, seal the result with the random beacon
+// and write the circuit-independent SRS to commons_path.
+func (c *Ceremony) Phase1_Verify(beacon []byte, commons_path string, contribution_paths ...string) error {
+	contributions, err := readPhase1(contribution_paths)
+	if err != nil {
+		return err
+	}
+
+	commons, err := mpcsetup.VerifyPhase1(c.Domain_Size, beacon, contributions...)
+	if err != nil {
+		return photoproof.Wrap(photoproof.ErrCeremony, err)
+	}
+	photoproof.Logger().Info("phase 1 verified", "contributions", len(contributions))
+
+	return writeTo(commons_path, &commons)
+}
+
+/*-------------------------------------------------Phase 2---------------------------------------------------*/
+
+// Write the empty Phase 2 object for the Admin's circuit, derived from the Phase 1 SRS
+func (c *Ceremony) Phase2_Init(commons_path string, out_path string) error {
+	var commons mpcsetup.SrsCommons
+	if err := readFrom(commons_path, &commons); err != nil {
+		return err
+	}
+
+	var p mpcsetup.Phase2
+	p.Initialize(c.Compliance_Predicate, &commons)
+
+	return writeTo(out_path, &p)
+}
+
+// Read the latest Phase 2 contribution from in_path, add this participant's randomness
+// and write the result to out_path. The randomness is discarded when this function returns.
+func Phase2_Contribute(in_path string, out_path string) error {
+	var p mpcsetup.Phase2
+	if err := readFrom(in_path, &p); err != nil {
+		return err
+	}
+
+	p.Contribute()
+
+	return writeTo(out_path, &p)
+}
+
+// Check that the contribution at next_path was correctly derived from the one at prev_path
+func Phase2_Verify_Contribution(prev_path string, next_path string) error {
+	var prev, next mpcsetup.Phase2
+	if err := readFrom(prev_path, &prev); err != nil {
+		return err
+	}
+	if err := readFrom(next_path, &next); err != nil {
+		return err
+	}
+
+	return photoproof.Wrap(photoproof.ErrCeremony, prev.Verify(&next))
+}
+
+// Verify every Phase 2 contribution in order, seal the result with the random beacon
+// and extract the PCD keys. Use NewCameraFromKeys() to build a Camera around them.
+func (c *Ceremony) Finalize(commons_path string, beacon []byte, contribution_paths ...string) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	var commons mpcsetup.SrsCommons
+	if err := readFrom(commons_path, &commons); err != nil {
+		return nil, nil, err
+	}
+
+	contributions, err := readPhase2(contribution_paths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provingKey, verifyingKey, err := mpcsetup.VerifyPhase2(c.Compliance_Predicate, &commons, beacon, contributions...)
+	if err != nil {
+		return nil, nil, photoproof.Wrap(photoproof.ErrCeremony, err)
+	}
+
+	photoproof.Logger().Info("ceremony finalized", "contributions", len(contributions))
+
+	return provingKey, verifyingKey, nil
+}
+
+/*-------------------------------------------------File Exchange---------------------------------------------*/
+
+func readPhase1(paths []string) ([]*mpcsetup.Phase1, error) {
+	contributions := make([]*mpcsetup.Phase1, len(paths))
+	for i, path := range paths {
+		contributions[i] = new(mpcsetup.Phase1)
+		if err := readFrom(path, contributions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return contributions, nil
+}
+
+func readPhase2(paths []string) ([]*mpcsetup.Phase2, error) {
+	contributions := make([]*mpcsetup.Phase2, len(paths))
+	for i, path := range paths {
+		contributions[i] = new(mpcsetup.Phase2)
+		if err := readFrom(path, contributions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return contributions, nil
+}
+
+// Write v to a new file at path
+func writeTo(path string, v io.WriterTo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := v.WriteTo(f); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return f.Close()
+}
+
+// Read v from the file at path. ReadFrom performs the basic sanity checks mpcsetup relies on.
+func readFrom(path string, v io.ReaderFrom) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := v.ReadFrom(f); err != nil {
+		return photoproof.Wrap(photoproof.ErrCeremony, fmt.Errorf("reading %s: %w", path, err))
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package camera
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// A small circuit, so the ceremony runs in a test: Y is the cube of the secret X
+type cubeCircuit struct {
+	X frontend.Variable `gnark:",secret"`
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubeCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+// Runs both phases of a ceremony with two contributors in dir, and returns the ceremony and its file paths
+func testCeremony(t *testing.T, dir string) (*Ceremony, map[string]string) {
+	t.Helper()
+	c, err := NewCeremony(&cubeCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := map[string]string{}
+	for _, name := range []string{"phase1_0", "phase1_1", "phase1_2", "commons", "phase2_0", "phase2_1", "phase2_2"} {
+		paths[name] = filepath.Join(dir, name)
+	}
+
+	if err := c.Phase1_Init(paths["phase1_0"]); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range [][2]string{{"phase1_0", "phase1_1"}, {"phase1_1", "phase1_2"}} {
+		if err := Phase1_Contribute(paths[step[0]], paths[step[1]]); err != nil {
+			t.Fatal(err)
+		}
+		if err := Phase1_Verify_Contribution(paths[step[0]], paths[step[1]]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Phase1_Verify([]byte("beacon 1"), paths["commons"], paths["phase1_1"], paths["phase1_2"]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Phase2_Init(paths["commons"], paths["phase2_0"]); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range [][2]string{{"phase2_0", "phase2_1"}, {"phase2_1", "phase2_2"}} {
+		if err := Phase2_Contribute(paths[step[0]], paths[step[1]]); err != nil {
+			t.Fatal(err)
+		}
+		if err := Phase2_Verify_Contribution(paths[step[0]], paths[step[1]]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c, paths
+}
+
+func TestCeremony(t *testing.T) {
+	c, paths := testCeremony(t, t.TempDir())
+
+	pk, vk, err := c.Finalize(paths["commons"], []byte("beacon 2"), paths["phase2_1"], paths["phase2_2"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The extracted keys prove and verify
+	witness, err := frontend.NewWitness(&cubeCircuit{X: 3, Y: 27}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(c.Compliance_Predicate, pk, witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := witness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, public); err != nil {
+		t.Fatal(err)
+	}
+
+	// ... and only for the proven statement
+	other, err := frontend.NewWitness(&cubeCircuit{Y: 28}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, other); err == nil {
+		t.Fatal("a proof verified for another public input")
+	}
+}
+
+// A contribution that does not build on the previous one is rejected, on its own and by the final verification
+func TestCeremony_Tampered(t *testing.T) {
+	dir := t.TempDir()
+	c, paths := testCeremony(t, dir)
+
+	// The second contributor of each phase starts over from the initial object, dropping the first contribution
+	forged1 := filepath.Join(dir, "phase1_forged")
+	if err := Phase1_Contribute(paths["phase1_0"], forged1); err != nil {
+		t.Fatal(err)
+	}
+	if err := Phase1_Verify_Contribution(paths["phase1_1"], forged1); !errors.Is(err, photoproof.ErrCeremony) {
+		t.Fatalf("verifying a forged phase 1 contribution returned %v, expected %v", err, photoproof.ErrCeremony)
+	}
+	if err := c.Phase1_Verify([]byte("beacon 1"), filepath.Join(dir, "forged_commons"), paths["phase1_1"], forged1); !errors.Is(err, photoproof.ErrCeremony) {
+		t.Fatalf("phase 1 with a forged contribution returned %v, expected %v", err, photoproof.ErrCeremony)
+	}
+
+	forged2 := filepath.Join(dir, "phase2_forged")
+	if err := Phase2_Contribute(paths["phase2_0"], forged2); err != nil {
+		t.Fatal(err)
+	}
+	if err := Phase2_Verify_Contribution(paths["phase2_1"], forged2); !errors.Is(err, photoproof.ErrCeremony) {
+		t.Fatalf("verifying a forged phase 2 contribution returned %v, expected %v", err, photoproof.ErrCeremony)
+	}
+	if _, _, err := c.Finalize(paths["commons"], []byte("beacon 2"), paths["phase2_1"], forged2); !errors.Is(err, photoproof.ErrCeremony) {
+		t.Fatalf("finalizing with a forged contribution returned %v, expected %v", err, photoproof.ErrCeremony)
+	}
+}
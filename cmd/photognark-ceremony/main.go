@@ -0,0 +1,159 @@
+// Command photognark-ceremony runs one step of the multi-party trusted setup for the PhotoGnark circuit.
+//
+// Each participant runs their step in their own process and passes the resulting file on:
+//
+//	photognark-ceremony phase1-init       -out phase1_0
+//	photognark-ceremony phase1-contribute -in phase1_0 -out phase1_1
+//	photognark-ceremony phase1-verify     -beacon <hex> -out commons phase1_1 ... phase1_n
+//	photognark-ceremony phase2-init       -commons commons -out phase2_0
+//	photognark-ceremony phase2-contribute -in phase2_0 -out phase2_1
+//	photognark-ceremony finalize          -commons commons -beacon <hex> -pk proving.key -vk verifying.key phase2_1 ... phase2_n
+//
+// Pass -private (and -hide-camera, -hide-editor) to every circuit-dependent step to run the ceremony for photoproof.Mode_Private,
+// or -region for photoproof.Mode_Region, -hash poseidon2 for photoproof.Mode_Poseidon2,
+// and -policy to run it for the Admin's policy file instead of photoproof.DefaultPolicy().
+//
+// Contributors can check the previous contribution with
+//
+//	photognark-ceremony phase1-check -prev phase1_0 -next phase1_1
+//	photognark-ceremony phase2-check -prev phase2_0 -next phase2_1
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+func main() {
+	photoproof.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "photognark-ceremony: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: photognark-ceremony <phase1-init|phase1-contribute|phase1-check|phase1-verify|phase2-init|phase2-contribute|phase2-check|finalize> [flags]")
+}
+
+func run(step string, args []string) error {
+	fs := flag.NewFlagSet(step, flag.ExitOnError)
+	in := fs.String("in", "", "previous contribution")
+	out := fs.String("out", "", "output file")
+	prev := fs.String("prev", "", "previous contribution to check against")
+	next := fs.String("next", "", "contribution to check")
+	commons := fs.String("commons", "", "Phase 1 output (SRS commons)")
+	beacon := fs.String("beacon", "", "hex encoded random beacon, evaluated after the last contribution")
+	pk := fs.String("pk", "proving.key", "output proving key")
+	vk := fs.String("vk", "verifying.key", "output verifying key")
+	private := fs.Bool("private", false, "run the ceremony for the hash-only PhotoGnark_Private circuit")
+	hideCamera := fs.Bool("hide-camera", false, "with -private, run the ceremony for the circuit that hides the camera")
+	hideEditor := fs.Bool("hide-editor", false, "with -private, run the ceremony for the circuit that hides the editor")
+	region := fs.Bool("region", false, "run the ceremony for the PhotoGnark_Region circuit, proving regions of originals")
+	hashName := fs.String("hash", image.Hash_MiMC.String(), "hash function of image hashes and signatures, mimc or poseidon2")
+	policyPath := fs.String("policy", "", "JSON policy of permissible transformations, defaults to every registered transformation")
+	fs.Parse(args)
+
+	switch step {
+	case "phase1-contribute":
+		return camera.Phase1_Contribute(*in, *out)
+	case "phase1-check":
+		return camera.Phase1_Verify_Contribution(*prev, *next)
+	case "phase2-contribute":
+		return camera.Phase2_Contribute(*in, *out)
+	case "phase2-check":
+		return camera.Phase2_Verify_Contribution(*prev, *next)
+	}
+
+	// The remaining steps depend on the circuit, which every participant compiles locally
+	mode := photoproof.Mode_Public
+	if *private {
+		mode = photoproof.Mode_Private
+	}
+	if *hideCamera {
+		mode |= photoproof.Mode_Hidden_Camera
+	}
+	if *hideEditor {
+		mode |= photoproof.Mode_Hidden_Editor
+	}
+	if *region {
+		mode |= photoproof.Mode_Region
+	}
+	hash, err := image.ParseHash(*hashName)
+	if err != nil {
+		return err
+	}
+	if hash == image.Hash_Poseidon2 {
+		mode |= photoproof.Mode_Poseidon2
+	}
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	policy := photoproof.DefaultPolicy()
+	if *policyPath != "" {
+		var err error
+		if policy, err = photoproof.LoadPolicy(*policyPath); err != nil {
+			return err
+		}
+	}
+	ceremony, err := camera.NewCeremony(photoproof.NewCircuit(mode, policy))
+	if err != nil {
+		return err
+	}
+
+	switch step {
+	case "phase1-init":
+		return ceremony.Phase1_Init(*out)
+	case "phase1-verify":
+		b, err := hex.DecodeString(*beacon)
+		if err != nil {
+			return err
+		}
+		return ceremony.Phase1_Verify(b, *out, fs.Args()...)
+	case "phase2-init":
+		return ceremony.Phase2_Init(*commons, *out)
+	case "finalize":
+		b, err := hex.DecodeString(*beacon)
+		if err != nil {
+			return err
+		}
+		provingKey, verifyingKey, err := ceremony.Finalize(*commons, b, fs.Args()...)
+		if err != nil {
+			return err
+		}
+		if err := writeKey(*pk, provingKey); err != nil {
+			return err
+		}
+		return writeKey(*vk, verifyingKey)
+	default:
+		usage()
+		return fmt.Errorf("unknown step %q", step)
+	}
+}
+
+func writeKey(path string, key io.WriterTo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := key.WriteTo(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
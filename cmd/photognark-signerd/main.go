@@ -0,0 +1,83 @@
+// Command photognark-signerd holds the camera's secret key in its own process, standing in for the HSM or
+// secure element of a real camera, and signs captured images for photognark over a Unix socket:
+//
+//	photognark-signerd -socket camera.sock -key camera.key
+//	photognark setup   -dir keys -signer camera.sock
+//	photognark capture -keys keys -signer camera.sock -in photo.png -out photo.bundle
+//
+// The key file is created if it does not exist. Only the daemon reads it, and only its owner can connect to
+// the socket. The daemon signs images, capture hashes and custody entries that it hashes itself, never a
+// digest it is handed. Every signature is logged to stderr.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+	"github.com/drakstik/PhotoGnark_ACDF/signerd"
+)
+
+func main() {
+	photoproof.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	socket := flag.String("socket", "camera.sock", "Unix socket to listen on")
+	key := flag.String("key", "camera.key", "hex encoded secret key file, created if it does not exist")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, *socket, *key); err != nil {
+		fmt.Fprintln(os.Stderr, "photognark-signerd: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, socket string, key string) error {
+	user, err := loadKey(key)
+	if err != nil {
+		return err
+	}
+
+	listener, err := signerd.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	photoproof.Logger().Info("signing daemon listening", "socket", socket, "public_key", hex.EncodeToString(user.PublicKey.Bytes()))
+	return signerd.Serve(ctx, listener, user.Signer)
+}
+
+// Secret keys are stored hex encoded, readable by their owner only, as photognark stores them
+func loadKey(path string) (photoproof.User, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		user, err := photoproof.NewUser()
+		if err != nil {
+			return photoproof.User{}, err
+		}
+		secret, err := user.SecretKeyBytes()
+		if err != nil {
+			return photoproof.User{}, err
+		}
+		return user, os.WriteFile(path, []byte(hex.EncodeToString(secret)+"\n"), 0o600)
+	}
+	if err != nil {
+		return photoproof.User{}, err
+	}
+
+	secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return photoproof.User{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return photoproof.NewUserFromBytes(secret)
+}
@@ -0,0 +1,1512 @@
+// Command photognark runs the PhotoGnark roles from the command line: the Admin's setup, the camera's capture,
+// an editor's edits and a verifier's checks. Photographs are exchanged as JSON proof bundles.
+//
+//	photognark setup   -dir keys [-policy policy.json] [-private [-hide-camera] [-hide-editor] | -region] [-hash poseidon2] [-signer camera.sock]
+//	photognark capture -keys keys -in photo.png -out photo.bundle [-signer camera.sock] [-log custody.json] [-tsa tsa.key] [-counter counter] [-metadata metadata.json]
+//	photognark edit    -keys keys -in photo.bundle -out edited.bundle -tr identity [-params '{}'] [-key editor.key] [-log custody.json]
+//	photognark disclose -keys keys -in photo.bundle -image published.png -area x,y,width,height -out region.bundle
+//	photognark verify  -vk keys/verifier.json -in edited.bundle [-revocations revocations.json [-revocation-window n]] [-json]
+//	photognark inspect -in edited.bundle [-vk keys/verifier.json] [-json]
+//	photognark custody -vk keys/verifier.json -in custody.json [-revocations revocations.json [-revocation-window n]] [-json]
+//	photognark embed   -in edited.bundle -out edited.png [-hide-camera]
+//	photognark export  -vk keys/verifier.json -in edited.bundle -out manifest.cbor
+//	photognark import  -vk keys/verifier.json -in manifest.cbor -image edited.png -out edited.bundle
+//	photognark serve-verify [-addr 127.0.0.1:8080] [-max-bytes n] [-revocations revocations.json [-revocation-window n]] keys/verifier.json ...
+//	photognark serve-edit   -keys keys -key editor.key -jobs jobs [-addr 127.0.0.1:8081] [-workers n] [-max-queue n]
+//	photognark keys    -keystore keystore [-key name] [-json]
+//	photognark pubkey  -key camera.key [-keystore keystore]
+//	photognark cameras -keys keys [public key ...]
+//	photognark revoke  -keys keys [-out revocations.json] [public key ...]
+//	photognark editors -keys keys [public key ...]
+//
+// setup writes prover.json and verifier.json, to share with provers and verifiers, and the camera's secret key
+// camera.key, which must stay on the camera. With -signer, the camera's key is held by a photognark-signerd
+// daemon listening on that Unix socket instead, and camera.key is neither written nor read.
+//
+// Every setup authorises the Admin's camera. cameras authorises more cameras, by their hex encoded public keys
+// (see pubkey), and rewrites prover.json and verifier.json: proofs are made and checked against the root of the
+// authorised cameras, so each camera signs with its own key under the same setup. With -hide-camera, proofs do
+// not reveal which camera took the photograph, and embed -hide-camera publishes a bundle without its key.
+//
+// revoke adds cameras, by their hex encoded public keys, to the Admin's revocation list in a new epoch, and
+// rewrites prover.json and verifier.json: a revoked camera's photographs can no longer be proven. With -out, the
+// list is also published to that file, which verifiers pass as -revocations to check proofs against the latest
+// list. Proofs must be made against the latest list, or within -revocation-window epochs of it: older proofs are
+// invalid until they are proven again, since they do not show that a hidden camera was not revoked since. A
+// revoked camera's photographs are invalid. verify reports the epoch each proof was made against.
+//
+// With -hide-editor, proofs do not reveal which editor made the last edit, only that the editor is one of the
+// Admin's: editors authorises editors by their hex encoded public keys and rewrites prover.json and verifier.json.
+// Bundles then leave out the editor's key and signature.
+//
+// With -region, the keys prove regions of originals instead of edits: disclose proves that the pixels of the
+// published image within the area are those of the captured photograph, and nothing about the rest of the image.
+// edit is not available with these keys.
+//
+// With -hash poseidon2, image hashes and signatures use Poseidon2 instead of MiMC, in and out of the circuit.
+// Commitments, the capture hash, Merkle trees, timestamp tokens and custody logs use MiMC either way.
+//
+// Secret keys are stored as hex files. With -keystore, they are stored encrypted in a keystore instead, with
+// the passphrase in $PHOTOGNARK_PASSPHRASE: -key then names a key of the keystore, and setup and capture keep
+// the camera's key under the name "camera". keys lists the keystore, after creating the -key if it is missing.
+//
+// With -log, capture starts a custody log of the photograph and every edit appends its step to it: transformation,
+// editor, output commitment, proof and timestamp, signed by the editor. custody verifies every step of the log.
+//
+// capture signs the capture time into the photograph, and verify reports it. With -tsa, the time is set by a
+// local timestamp authority holding that key instead of the camera's clock, and the photograph carries its token.
+// With -counter, the photograph is also numbered by the camera's capture counter, kept in that file. With
+// -metadata, the capture metadata (image.Metadata as JSON: device serial, GPS location, exposure, focal length) is
+// hashed with the pixels. Edits carry it over, except edit -tr redact_gps, which strips the GPS location.
+//
+// export writes a C2PA-style manifest, as CBOR or as JSON (.json). import rebuilds the bundle from a manifest
+// and the image's pixels.
+//
+// Wherever a bundle is read or written, a PNG file (.png) can be used instead: the bundle is embedded in
+// the PNG's private "pgNK" chunk and verified against the PNG's own pixels.
+//
+// Exit codes:
+//
+//	0  success, or the bundle is valid
+//	1  the bundle is invalid
+//	2  usage error
+//	3  any other error (files, decoding, proving)
+//	4  the edit is not permitted by the Admin's policy, or the camera is not authorised or revoked
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/drakstik/PhotoGnark_ACDF/c2pa"
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/keystore"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+	"github.com/drakstik/PhotoGnark_ACDF/service"
+	"github.com/drakstik/PhotoGnark_ACDF/signerd"
+	"github.com/drakstik/PhotoGnark_ACDF/tsa"
+)
+
+const (
+	exitOK       = 0
+	exitInvalid  = 1
+	exitUsage    = 2
+	exitError    = 3
+	exitRejected = 4
+)
+
+// Files written by setup
+const (
+	proverFile   = "prover.json"
+	verifierFile = "verifier.json"
+	cameraFile   = "camera.key"
+	cameraKey    = "camera" // Name of the camera's key in a keystore
+)
+
+// Environment variable holding the keystore's passphrase
+const passphraseEnv = "PHOTOGNARK_PASSPHRASE"
+
+var errUsage = errors.New("usage")
+
+func main() {
+	photoproof.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1], os.Args[2:])
+	if err != nil && !errors.Is(err, errInvalid) {
+		fmt.Fprintln(os.Stderr, "photognark: "+err.Error())
+	}
+	os.Exit(exitCode(err))
+}
+
+// Flags of every command, as in the package documentation
+const synopsis = `	photognark setup   -dir keys [-policy policy.json] [-private [-hide-camera] [-hide-editor] | -region] [-hash poseidon2] [-signer camera.sock]
+	photognark capture -keys keys -in photo.png -out photo.bundle [-signer camera.sock] [-log custody.json] [-tsa tsa.key] [-counter counter] [-metadata metadata.json]
+	photognark edit    -keys keys -in photo.bundle -out edited.bundle -tr identity [-params '{}'] [-key editor.key] [-log custody.json]
+	photognark disclose -keys keys -in photo.bundle -image published.png -area x,y,width,height -out region.bundle
+	photognark verify  -vk keys/verifier.json -in edited.bundle [-revocations revocations.json [-revocation-window n]] [-json]
+	photognark inspect -in edited.bundle [-vk keys/verifier.json] [-json]
+	photognark custody -vk keys/verifier.json -in custody.json [-revocations revocations.json [-revocation-window n]] [-json]
+	photognark embed   -in edited.bundle -out edited.png [-hide-camera]
+	photognark export  -vk keys/verifier.json -in edited.bundle -out manifest.cbor
+	photognark import  -vk keys/verifier.json -in manifest.cbor -image edited.png -out edited.bundle
+	photognark serve-verify [-addr 127.0.0.1:8080] [-max-bytes n] [-revocations revocations.json [-revocation-window n]] keys/verifier.json ...
+	photognark serve-edit   -keys keys -key editor.key -jobs jobs [-addr 127.0.0.1:8081] [-workers n] [-max-queue n]
+	photognark keys    -keystore keystore [-key name] [-json]
+	photognark pubkey  -key camera.key [-keystore keystore]
+	photognark cameras -keys keys [public key ...]
+	photognark revoke  -keys keys [-out revocations.json] [public key ...]
+	photognark editors -keys keys [public key ...]
+`
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: photognark <setup|capture|edit|disclose|verify|inspect|custody|embed|export|import|serve-verify|serve-edit|keys|pubkey|cameras|revoke|editors> [flags]")
+	fmt.Fprint(os.Stderr, synopsis)
+	fmt.Fprintln(os.Stderr, "run photognark <command> -h for the flags of a command")
+}
+
+// Maps an error to the command's exit code
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errInvalid), errors.Is(err, photoproof.ErrNoProof):
+		return exitInvalid
+	case errors.Is(err, photoproof.ErrNotPermitted), errors.Is(err, photoproof.ErrBudgetExceeded), errors.Is(err, photoproof.ErrNotAuthorised), errors.Is(err, photoproof.ErrRevoked):
+		return exitRejected
+	default:
+		return exitError
+	}
+}
+
+// Runs the command with its own flags: a flag of another command is a usage error, not silently ignored
+func run(ctx context.Context, command string, args []string) error {
+	f := flags{flag.NewFlagSet(command, flag.ContinueOnError)}
+
+	switch command {
+	case "setup":
+		dir := f.String("dir", "photognark", "output directory of the Admin's keys")
+		policyPath := f.String("policy", "", "JSON policy of permissible transformations, defaults to every registered transformation")
+		private := f.Bool("private", false, "generate keys for the hash-only PhotoGnark_Private circuit")
+		hideCamera := f.Bool("hide-camera", false, "with -private, generate keys that hide which camera took the photograph")
+		hideEditor := f.Bool("hide-editor", false, "with -private, generate keys that hide which authorised editor made the last edit")
+		region := f.Bool("region", false, "generate keys proving regions of originals instead of edits")
+		hashName := f.String("hash", image.Hash_MiMC.String(), "hash function of image hashes and signatures, mimc or poseidon2")
+		signer := f.signer()
+		secrets := f.secrets()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return setup(ctx, *dir, *policyPath, *private, *hideCamera, *hideEditor, *region, *hashName, *signer, *secrets)
+
+	case "capture":
+		keys := f.keys()
+		in := f.String("in", "", "input PNG")
+		out := f.out("output bundle")
+		signer := f.signer()
+		custodyLog := f.String("log", "", "custody log to start, appended to by edit")
+		tsaKey := f.String("tsa", "", "secret key file of the local timestamp authority setting the capture time, or name in the -keystore, created if it does not exist")
+		counter := f.String("counter", "", "file of the camera's capture counter, incremented by every capture")
+		metadata := f.String("metadata", "", "JSON capture metadata: device_serial, gps {latitude, longitude} in microdegrees, exposure in µs, focal_length in µm")
+		secrets := f.secrets()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return capture(ctx, *keys, *in, *out, *signer, *custodyLog, *tsaKey, *counter, *metadata, *secrets)
+
+	case "edit":
+		keys := f.keys()
+		in := f.in()
+		out := f.out("output bundle")
+		tr := f.String("tr", "identity", "name of the transformation to apply")
+		params := f.String("params", "", "JSON parameters of the transformation")
+		key := f.key("editor's secret key file, or name in the -keystore, created if it does not exist. A new key is used if empty")
+		custodyLog := f.String("log", "", "custody log of the photograph, started by capture, to append the edit to")
+		secrets := f.secrets()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return edit(ctx, *keys, *in, *out, *tr, *params, *key, *custodyLog, *secrets)
+
+	case "disclose":
+		keys := f.keys()
+		in := f.in()
+		pixels := f.String("image", "", "PNG of the published image")
+		area := f.String("area", "", "area of the published image proven to be the original's, as x,y,width,height")
+		out := f.out("output region bundle")
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return disclose(ctx, *keys, *in, *pixels, *area, *out)
+
+	case "verify", "custody":
+		vk := f.vk()
+		in := f.in()
+		revocations, window := f.revocations()
+		asJSON := f.json()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		if command == "custody" {
+			return custody(*vk, *in, *revocations, *window, *asJSON)
+		}
+		return verify(*vk, *in, *revocations, *window, *asJSON)
+
+	case "inspect":
+		in := f.in()
+		vk := f.String("vk", "", "verifier keys, to decode the provenance with their policy")
+		asJSON := f.json()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return inspect(*vk, *in, *asJSON)
+
+	case "embed":
+		in := f.in()
+		out := f.out("output PNG")
+		hideCamera := f.Bool("hide-camera", false, "leave the camera's key out of the embedded bundle")
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return embed(*in, *out, *hideCamera)
+
+	case "export":
+		vk := f.vk()
+		in := f.in()
+		out := f.out("output manifest, CBOR or JSON (.json)")
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return export(*vk, *in, *out)
+
+	case "import":
+		vk := f.vk()
+		in := f.String("in", "", "input manifest")
+		pixels := f.String("image", "", "PNG of the manifest's image")
+		out := f.out("output bundle")
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return importManifest(*vk, *in, *pixels, *out)
+
+	case "serve-verify":
+		addr := f.String("addr", "127.0.0.1:8080", "address the service listens on")
+		maxBytes := f.maxBytes()
+		revocations, window := f.revocations()
+		if err := f.parseArgs(args); err != nil {
+			return err
+		}
+		return serveVerify(ctx, *addr, *maxBytes, *revocations, *window, f.Args())
+
+	case "serve-edit":
+		addr := f.String("addr", "127.0.0.1:8081", "address the service listens on")
+		keys := f.keys()
+		key := f.key("editor's secret key file, or name in the -keystore, created if it does not exist. A new key is used if empty")
+		jobs := f.String("jobs", "jobs", "directory where the editing service persists its jobs")
+		workers := f.Int("workers", 0, "number of edits proven at once, defaults to the number of CPUs")
+		maxQueue := f.Int("max-queue", service.Default_Max_Queue, "largest number of edits waiting for a worker")
+		maxBytes := f.maxBytes()
+		secrets := f.secrets()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return serveEdit(ctx, *addr, *keys, *key, *secrets, &service.Editor{Dir: *jobs, Workers: *workers, Max_Queue: *maxQueue, Max_Bytes: *maxBytes})
+
+	case "keys":
+		key := f.key("name of a key of the keystore, created if it does not exist")
+		asJSON := f.json()
+		secrets := f.secrets()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return listKeys(*key, *secrets, *asJSON)
+
+	case "pubkey":
+		key := f.key("secret key file, or name in the -keystore")
+		secrets := f.secrets()
+		if err := f.parse(args); err != nil {
+			return err
+		}
+		return pubkey(*key, *secrets)
+
+	case "cameras", "editors":
+		keys := f.keys()
+		if err := f.parseArgs(args); err != nil {
+			return err
+		}
+		if command == "editors" {
+			return editors(*keys, f.Args())
+		}
+		return cameras(*keys, f.Args())
+
+	case "revoke":
+		keys := f.keys()
+		out := f.out("file to publish the revocation list to, for verifiers")
+		if err := f.parseArgs(args); err != nil {
+			return err
+		}
+		return revoke(*keys, *out, f.Args())
+
+	default:
+		usage()
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+// The flags of one command. Flags shared by several commands are defined by the methods below.
+type flags struct {
+	*flag.FlagSet
+}
+
+func (f flags) keys() *string {
+	return f.String("keys", "photognark", "directory of the Admin's keys, written by setup")
+}
+
+func (f flags) vk() *string {
+	return f.String("vk", "", "trusted verifier keys, written by setup")
+}
+
+func (f flags) in() *string {
+	return f.String("in", "", "input bundle")
+}
+
+func (f flags) out(usage string) *string {
+	return f.String("out", "", usage)
+}
+
+func (f flags) key(usage string) *string {
+	return f.String("key", "", usage)
+}
+
+func (f flags) signer() *string {
+	return f.String("signer", "", "Unix socket of the photognark-signerd daemon holding the camera's key")
+}
+
+func (f flags) json() *bool {
+	return f.Bool("json", false, "print machine-readable JSON")
+}
+
+func (f flags) maxBytes() *int64 {
+	return f.Int64("max-bytes", service.Default_Max_Bytes, "largest accepted upload")
+}
+
+func (f flags) revocations() (*string, *uint64) {
+	return f.String("revocations", "", "revocation list published by revoke, replaces the verifier keys' own list"),
+		f.Uint64("revocation-window", 0, "number of epochs a proof's revocation list may lag behind the latest one, proofs of older epochs are invalid")
+}
+
+// The keystore is read once the flags are parsed
+func (f flags) secrets() *secretKeys {
+	secrets := &secretKeys{}
+	f.StringVar(&secrets.keystore, "keystore", "", "directory of passphrase-encrypted secret keys, see "+passphraseEnv)
+	return secrets
+}
+
+// Parse the command's flags, the command takes no other arguments
+func (f flags) parse(args []string) error {
+	if err := f.parseArgs(args); err != nil {
+		return err
+	}
+	if f.NArg() > 0 {
+		return fmt.Errorf("%w: %s takes no arguments, got %q", errUsage, f.Name(), f.Arg(0))
+	}
+	return nil
+}
+
+// Parse the command's flags, the arguments that follow them are f.Args()
+func (f flags) parseArgs(args []string) error {
+	if err := f.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+/*-----------------------------------------------------Admin-----------------------------------------------------*/
+
+func setup(ctx context.Context, dir string, policyPath string, private bool, hideCamera bool, hideEditor bool, region bool, hashName string, signer string, secrets secretKeys) error {
+	policy := photoproof.DefaultPolicy()
+	if policyPath != "" {
+		var err error
+		if policy, err = photoproof.LoadPolicy(policyPath); err != nil {
+			return err
+		}
+	}
+
+	mode := photoproof.Mode_Public
+	if private {
+		mode = photoproof.Mode_Private
+	}
+	if hideCamera {
+		mode |= photoproof.Mode_Hidden_Camera
+	}
+	if hideEditor {
+		mode |= photoproof.Mode_Hidden_Editor
+	}
+	if region {
+		mode |= photoproof.Mode_Region
+	}
+	hash, err := image.ParseHash(hashName)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if hash == image.Hash_Poseidon2 {
+		mode |= photoproof.Mode_Poseidon2
+	}
+	if err := mode.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	admin, err := newAdmin(signer)
+	if err != nil {
+		return err
+	}
+	if client, ok := admin.Signer.(*signerd.Client); ok {
+		defer client.Close()
+	}
+
+	prover, verifier, err := camera.Setup(ctx, admin, mode, policy)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, proverFile), prover); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, verifierFile), verifier); err != nil {
+		return err
+	}
+	if signer == "" {
+		if err := secrets.write(secrets.camera(dir), admin); err != nil {
+			return err
+		}
+	}
+
+	fingerprint, err := verifier.Fingerprint()
+	if err != nil {
+		return err
+	}
+	fmt.Println("verifying key " + fingerprint)
+	fmt.Println("camera public key " + hex.EncodeToString(admin.PublicKey.Bytes()))
+
+	return nil
+}
+
+// Authorise more cameras and rewrite the Admin's keys
+func cameras(keys string, publicKeys []string) error {
+	prover, verifier, err := readKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	authorised, err := decodePublicKeys(publicKeys)
+	if err != nil {
+		return err
+	}
+	for _, key := range authorised {
+		if err := prover.Cameras.Add(key); err != nil {
+			return err
+		}
+	}
+
+	if len(publicKeys) > 0 {
+		verifier.Cameras = prover.Cameras
+		if err := writeFile(filepath.Join(keys, proverFile), prover); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(keys, verifierFile), verifier); err != nil {
+			return err
+		}
+	}
+
+	root, err := prover.Cameras.Root()
+	if err != nil {
+		return err
+	}
+	fmt.Println("cameras root " + hex.EncodeToString(root))
+	for _, key := range prover.Cameras.Keys {
+		fmt.Println("  " + photoproof.Fingerprint(key))
+	}
+	return nil
+}
+
+// Revoke cameras in a new epoch, rewrite the Admin's keys and publish the revocation list to out
+func revoke(keys string, out string, publicKeys []string) error {
+	prover, verifier, err := readKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := decodePublicKeys(publicKeys)
+	if err != nil {
+		return err
+	}
+	if len(revoked) > 0 {
+		if err := prover.Revocations.Revoke(revoked...); err != nil {
+			return err
+		}
+		verifier.Revocations = prover.Revocations
+		if err := writeFile(filepath.Join(keys, proverFile), prover); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(keys, verifierFile), verifier); err != nil {
+			return err
+		}
+	}
+	if out != "" {
+		if err := writeFile(out, prover.Revocations); err != nil {
+			return err
+		}
+	}
+
+	root, err := prover.Revocations.Root()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revocation epoch %d, root %s\n", prover.Revocations.Epoch, hex.EncodeToString(root))
+	for _, revocation := range prover.Revocations.Revocations {
+		fmt.Printf("  %s (epoch %d)\n", photoproof.Fingerprint(revocation.Key), revocation.Epoch)
+	}
+	return nil
+}
+
+// Authorise more editors and rewrite the Admin's keys
+func editors(keys string, publicKeys []string) error {
+	prover, verifier, err := readKeys(keys)
+	if err != nil {
+		return err
+	}
+	if !prover.Mode.Hidden_Editor() {
+		return fmt.Errorf("%w: the keys in %s do not hide the editor, any editor can edit, see setup -hide-editor", errUsage, keys)
+	}
+
+	authorised, err := decodePublicKeys(publicKeys)
+	if err != nil {
+		return err
+	}
+	for _, key := range authorised {
+		if err := prover.Editors.Add(key); err != nil {
+			return err
+		}
+	}
+
+	if len(publicKeys) > 0 {
+		verifier.Editors = prover.Editors
+		if err := writeFile(filepath.Join(keys, proverFile), prover); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(keys, verifierFile), verifier); err != nil {
+			return err
+		}
+	}
+
+	root, err := prover.Editors.Root()
+	if err != nil {
+		return err
+	}
+	fmt.Println("editors root " + hex.EncodeToString(root))
+	for _, key := range prover.Editors.Keys {
+		fmt.Println("  " + photoproof.Fingerprint(key))
+	}
+	return nil
+}
+
+// Decode hex encoded public keys given on the command line
+func decodePublicKeys(encoded []string) ([]signature.PublicKey, error) {
+	keys := []signature.PublicKey{}
+	for _, e := range encoded {
+		data, err := hex.DecodeString(e)
+		if err != nil {
+			return nil, fmt.Errorf("%w: public key %q: %w", errUsage, e, err)
+		}
+		key, err := photoproof.DecodePublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+/*-----------------------------------------------------Camera----------------------------------------------------*/
+
+func capture(ctx context.Context, keys string, in string, out string, signer string, custodyLog string, tsaKey string, counter string, metadata string, secrets secretKeys) error {
+	if in == "" || out == "" {
+		return fmt.Errorf("%w: capture requires -in and -out", errUsage)
+	}
+
+	prover, verifier, err := readKeys(keys)
+	if err != nil {
+		return err
+	}
+	admin, err := readAdmin(keys, signer, secrets)
+	if err != nil {
+		return err
+	}
+	if client, ok := admin.Signer.(*signerd.Client); ok {
+		defer client.Close()
+	}
+	if prover.Cameras.Index(admin.PublicKey) < 0 {
+		return fmt.Errorf("%w: the camera's key is not authorised by %s, see cameras", photoproof.ErrNotAuthorised, keys)
+	}
+	if prover.Revocations.IsRevoked(admin.PublicKey) {
+		return fmt.Errorf("%w: the camera's key was revoked by %s", photoproof.ErrRevoked, keys)
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, err := image.FromPNG(f)
+	if err != nil {
+		return err
+	}
+	if metadata != "" {
+		data, err := os.ReadFile(metadata)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &img.Metadata); err != nil {
+			return fmt.Errorf("%w: %s: %w", photoproof.ErrInvalidMetadata, metadata, err)
+		}
+	}
+
+	cam := camera.Camera{Admin: admin, ProvingKey: prover, VerifyingKey: verifier}
+	if tsaKey != "" {
+		authority, err := editorKey(tsaKey, secrets)
+		if err != nil {
+			return err
+		}
+		cam.Timestamper = tsa.New(authority)
+	}
+	if counter != "" {
+		cam.Use_Counter = true
+		if cam.Counter, err = readCounter(counter); err != nil {
+			return err
+		}
+	}
+
+	photo, err := cam.Capture(ctx, img)
+	if err != nil {
+		return err
+	}
+	if counter != "" {
+		if err := os.WriteFile(counter, []byte(strconv.FormatUint(cam.Counter, 10)+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+
+	if custodyLog != "" {
+		log, err := photo.StartCustody(admin)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(custodyLog, log); err != nil {
+			return err
+		}
+	}
+
+	return writeBundle(out, photo)
+}
+
+/*-----------------------------------------------------Editor----------------------------------------------------*/
+
+func edit(ctx context.Context, keys string, in string, out string, tr string, params string, key string, custodyLog string, secrets secretKeys) error {
+	if in == "" || out == "" {
+		return fmt.Errorf("%w: edit requires -in and -out", errUsage)
+	}
+
+	reg, ok := photoproof.Lookup(tr)
+	if !ok {
+		return fmt.Errorf("%w: %q", photoproof.ErrUnknownTransformation, tr)
+	}
+	parameters, err := photoproof.ParseParameters(tr, []byte(params))
+	if err != nil {
+		return err
+	}
+
+	prover, verifier, err := readKeys(keys)
+	if err != nil {
+		return err
+	}
+	editor, err := editorKey(key, secrets)
+	if err != nil {
+		return err
+	}
+
+	photo, err := readPhotograph(in, prover, verifier)
+	if err != nil {
+		return err
+	}
+	if custodyLog != "" {
+		if photo.Custody, err = readCustodyLog(custodyLog, photo); err != nil {
+			return err
+		}
+	}
+
+	edited, err := editor.Edit(ctx, photo, reg.Transformation, parameters)
+	if err != nil {
+		return err
+	}
+
+	if custodyLog != "" {
+		if err := writeFile(custodyLog, edited.Custody); err != nil {
+			return err
+		}
+	}
+	return writeBundle(out, edited)
+}
+
+// Prove that the published image has the pixels of the original photograph within the area
+func disclose(ctx context.Context, keys string, in string, pixels string, area string, out string) error {
+	if in == "" || pixels == "" || area == "" || out == "" {
+		return fmt.Errorf("%w: disclose requires -in, -image, -area and -out", errUsage)
+	}
+
+	var region image.Area
+	if _, err := fmt.Sscanf(area, "%d,%d,%d,%d", &region.Loc.X, &region.Loc.Y, &region.Width, &region.Height); err != nil {
+		return fmt.Errorf("%w: -area %q is not x,y,width,height", errUsage, area)
+	}
+
+	prover, verifier, err := readKeys(keys)
+	if err != nil {
+		return err
+	}
+	original, err := readPhotograph(in, prover, verifier)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(pixels)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	published, err := image.FromPNG(f)
+	if err != nil {
+		return err
+	}
+
+	photo, err := photoproof.ProveRegion(ctx, original, published, region)
+	if err != nil {
+		return err
+	}
+	return writeBundle(out, photo)
+}
+
+// Returns the editor's key, creating it if it does not exist, or a new key if key is empty
+func editorKey(key string, secrets secretKeys) (photoproof.User, error) {
+	if key != "" && secrets.exists(key) {
+		return secrets.read(key)
+	}
+
+	editor, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	if key != "" {
+		if err := secrets.write(key, editor); err != nil {
+			return photoproof.User{}, err
+		}
+	}
+	return editor, nil
+}
+
+// Lists the keystore's keys, after creating key if it is set and missing
+func listKeys(key string, secrets secretKeys, asJSON bool) error {
+	if secrets.keystore == "" {
+		return fmt.Errorf("%w: keys requires -keystore", errUsage)
+	}
+	if key != "" {
+		if _, err := editorKey(key, secrets); err != nil {
+			return err
+		}
+	}
+
+	ks, err := keystore.Open(secrets.keystore)
+	if err != nil {
+		return err
+	}
+	entries, err := ks.List()
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		_, err := writeJSON(os.Stdout, entries)
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tFINGERPRINT\tCREATED")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Name, entry.Fingerprint, entry.Created.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// Print the hex encoded public key of a secret key, e.g. for cameras
+func pubkey(key string, secrets secretKeys) error {
+	if key == "" {
+		return fmt.Errorf("%w: pubkey requires -key", errUsage)
+	}
+	user, err := secrets.read(key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(user.PublicKey.Bytes()))
+	return nil
+}
+
+/*----------------------------------------------------Verifier---------------------------------------------------*/
+
+// Returned by verify when the bundle is invalid, after printing the verdict
+var errInvalid = errors.New("invalid bundle")
+
+func verify(vkPath string, in string, revocations string, window uint64, asJSON bool) error {
+	if vkPath == "" || in == "" {
+		return fmt.Errorf("%w: verify requires -vk and -in", errUsage)
+	}
+
+	verifier, err := readVerifierKeys(vkPath)
+	if err != nil {
+		return err
+	}
+	if err := readRevocations(revocations, window, &verifier); err != nil {
+		return err
+	}
+	bundle, err := readBundle(in)
+	if err != nil {
+		return err
+	}
+
+	verdict := photoproof.VerifyBundle(bundle, verifier)
+
+	if asJSON {
+		if _, err := writeJSON(os.Stdout, verdict); err != nil {
+			return err
+		}
+	} else if verdict.Valid {
+		fmt.Println("valid, verifying key " + verdict.VerifyingKey)
+		fmt.Println("cameras root " + verdict.Cameras_Root)
+		fmt.Println("camera " + cmp.Or(verdict.Camera, "hidden"))
+		if verdict.Area != nil {
+			fmt.Printf("region %dx%d at (%d, %d), the rest of the image is not proven\n", verdict.Area.Width, verdict.Area.Height, verdict.Area.Loc.X, verdict.Area.Loc.Y)
+		} else if verdict.Editors_Root != "" {
+			fmt.Println("editor hidden, editors root " + verdict.Editors_Root)
+		} else {
+			fmt.Println("editor " + verdict.Editor)
+		}
+		fmt.Printf("revocation epoch %d of %d\n", verdict.Revocation_Epoch, verdict.Revocation_Latest)
+		captured := "captured " + verdict.Captured.Format(time.RFC3339)
+		if verdict.Counter > 0 {
+			captured += fmt.Sprintf(", counter %d", verdict.Counter)
+		}
+		if verdict.Timestamp_Authority != "" {
+			captured += ", timestamp authority " + verdict.Timestamp_Authority
+		} else {
+			captured += ", camera clock"
+		}
+		fmt.Println(captured)
+		if verdict.Area == nil {
+			fmt.Println(describeMetadata(verdict.Metadata))
+		}
+		if verdict.Provenance != nil {
+			fmt.Print(verdict.Provenance)
+		}
+	} else {
+		fmt.Println("invalid: " + verdict.Error)
+	}
+
+	if !verdict.Valid {
+		return errInvalid
+	}
+	return nil
+}
+
+func custody(vkPath string, in string, revocations string, window uint64, asJSON bool) error {
+	if vkPath == "" || in == "" {
+		return fmt.Errorf("%w: custody requires -vk and -in", errUsage)
+	}
+
+	verifier, err := readVerifierKeys(vkPath)
+	if err != nil {
+		return err
+	}
+	if err := readRevocations(revocations, window, &verifier); err != nil {
+		return err
+	}
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	log, err := photoproof.ReadCustodyLog(f)
+	if err != nil {
+		return err
+	}
+
+	verdict := photoproof.VerifyCustody(*log, verifier)
+
+	if asJSON {
+		if _, err := writeJSON(os.Stdout, verdict); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STEP\tTRANSFORMATION\tEDITOR\tTIMESTAMP\tVALID")
+		for _, link := range verdict.Links {
+			valid := "valid"
+			if !link.Valid {
+				valid = "invalid: " + link.Error
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", link.Step, link.Transformation, link.Editor[:16], link.Timestamp.Format(time.RFC3339), valid)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if !verdict.Valid {
+		return errInvalid
+	}
+	return nil
+}
+
+// Summary printed by inspect
+type summary struct {
+	VerifyingKey       string             `json:"verifying_key"`
+	Trusted            *bool              `json:"trusted,omitempty"` // Set when -vk is given
+	Original_PublicKey string             `json:"original_public_key"`
+	PublicKey          string             `json:"public_key"`
+	Original_Hash      string             `json:"original_hash"`
+	Captured           time.Time          `json:"captured"`
+	Counter            uint64             `json:"counter,omitempty"`
+	Metadata           image.Metadata     `json:"metadata"`
+	Image_Hash         string             `json:"image_hash"`
+	Edited             bool               `json:"edited"`
+	Area               *image.Area        `json:"area,omitempty"` // Set for region proofs
+	Pixels             []string           `json:"pixels"`         // One row of hex encoded RGB values per line
+	Provenance         []image.Provenance `json:"provenance"`
+	Report             *photoproof.Report `json:"report,omitempty"`
+	Report_Error       string             `json:"report_error,omitempty"`
+}
+
+func inspect(vkPath string, in string, asJSON bool) error {
+	if in == "" {
+		return fmt.Errorf("%w: inspect requires -in", errUsage)
+	}
+
+	bundle, err := readBundle(in)
+	if err != nil {
+		return err
+	}
+
+	s := summary{
+		VerifyingKey:       bundle.VerifyingKey_Fingerprint,
+		Original_PublicKey: "hidden",
+		PublicKey:          "hidden",
+		Original_Hash:      hex.EncodeToString(bundle.Original_Hash),
+		Captured:           time.Unix(int64(bundle.Capture.Time), 0).UTC(),
+		Counter:            bundle.Capture.Counter,
+		Metadata:           bundle.Image.Metadata,
+		Image_Hash:         hex.EncodeToString(image.ImageHash(bundle.Image)),
+		Edited:             !bytes.Equal(image.CaptureHash(bundle.Image, bundle.Capture), bundle.Original_Hash),
+		Provenance:         bundle.Image.Provenance[:],
+		Area:               bundle.Area,
+	}
+	if len(bundle.Original_PublicKey) > 0 {
+		s.Original_PublicKey = photoproof.Fingerprint(bundle.Original_PublicKey)
+	}
+	if len(bundle.PublicKey) > 0 {
+		s.PublicKey = photoproof.Fingerprint(bundle.PublicKey)
+	}
+	for row := 0; row < int(image.N); row++ {
+		pixels := []string{}
+		for col := 0; col < int(image.N); col++ {
+			rgb := bundle.Image.Pxls[row*int(image.N)+col].RGB
+			pixels = append(pixels, hex.EncodeToString(rgb[:]))
+		}
+		s.Pixels = append(s.Pixels, strings.Join(pixels, " "))
+	}
+
+	// Decode the provenance with the trusted policy, if any
+	if vkPath != "" {
+		verifier, err := readVerifierKeys(vkPath)
+		if err != nil {
+			return err
+		}
+		fingerprint, err := verifier.Fingerprint()
+		if err != nil {
+			return err
+		}
+		trusted := fingerprint == bundle.VerifyingKey_Fingerprint
+		s.Trusted = &trusted
+
+		// A region proof says nothing about the provenance of the published image
+		if s.Area == nil {
+			report, err := photoproof.NewReport(bundle.Image, verifier.Policy)
+			if err != nil {
+				s.Report_Error = err.Error()
+			} else {
+				s.Report = &report
+			}
+		}
+	}
+
+	if asJSON {
+		_, err := writeJSON(os.Stdout, s)
+		return err
+	}
+
+	fmt.Println("verifying key       " + s.VerifyingKey)
+	if s.Trusted != nil {
+		fmt.Printf("trusted             %t\n", *s.Trusted)
+	}
+	fmt.Println("original public key " + s.Original_PublicKey)
+	fmt.Println("editor public key   " + s.PublicKey)
+	fmt.Println("original hash       " + s.Original_Hash)
+	fmt.Println("captured            " + s.Captured.Format(time.RFC3339))
+	if s.Counter > 0 {
+		fmt.Printf("counter             %d\n", s.Counter)
+	}
+	fmt.Println("metadata            " + describeMetadata(s.Metadata))
+	fmt.Println("image hash          " + s.Image_Hash)
+	fmt.Printf("edited              %t\n", s.Edited)
+	if s.Area != nil {
+		fmt.Printf("region              %dx%d at (%d, %d)\n", s.Area.Width, s.Area.Height, s.Area.Loc.X, s.Area.Loc.Y)
+	}
+	fmt.Println("pixels")
+	for _, row := range s.Pixels {
+		fmt.Println("  " + row)
+	}
+	fmt.Println("provenance")
+	for i, prov := range s.Provenance {
+		fmt.Printf("  %d: id %d, bound %d\n", i, prov.Tr_Name, prov.Tr_Bound)
+	}
+	if s.Report != nil {
+		fmt.Print(s.Report)
+	}
+	if s.Report_Error != "" {
+		fmt.Println(s.Report_Error)
+	}
+
+	return nil
+}
+
+// Returns a one line description of capture metadata
+func describeMetadata(md image.Metadata) string {
+	gps := "none"
+	if md.GPS != nil {
+		gps = fmt.Sprintf("%.6f, %.6f", float64(md.GPS.Latitude)/1e6, float64(md.GPS.Longitude)/1e6)
+	} else if md.GPS_Redacted() {
+		gps = "redacted"
+	}
+	return fmt.Sprintf("device %s, gps %s, exposure %d µs, focal length %d µm", cmp.Or(md.Device_Serial, "unknown"), gps, md.Exposure, md.Focal_Length)
+}
+
+// Convert between a bundle and a PNG with its bundle embedded, depending on the extension of out
+func embed(in string, out string, hideCamera bool) error {
+	if in == "" || out == "" {
+		return fmt.Errorf("%w: embed requires -in and -out", errUsage)
+	}
+
+	bundle, err := readBundle(in)
+	if err != nil {
+		return err
+	}
+	if hideCamera {
+		bundle = bundle.HideCamera()
+	}
+	return writeFile(out, bundleFile{bundle, filepath.Ext(out) == ".png"})
+}
+
+// Write the bundle's C2PA-style manifest, as JSON if out ends with .json and as CBOR otherwise
+func export(vkPath string, in string, out string) error {
+	if vkPath == "" || in == "" || out == "" {
+		return fmt.Errorf("%w: export requires -vk, -in and -out", errUsage)
+	}
+
+	verifier, err := readVerifierKeys(vkPath)
+	if err != nil {
+		return err
+	}
+	photo, err := readPhotograph(in, photoproof.ProverKeys{}, verifier)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := c2pa.Export(photo)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if filepath.Ext(out) == ".json" {
+		data, err = manifest.JSON()
+	} else {
+		data, err = manifest.CBOR()
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
+
+// Rebuild a bundle from a C2PA-style manifest, JSON or CBOR, and the PNG of its image
+func importManifest(vkPath string, in string, pixels string, out string) error {
+	if vkPath == "" || in == "" || pixels == "" || out == "" {
+		return fmt.Errorf("%w: import requires -vk, -in, -image and -out", errUsage)
+	}
+
+	verifier, err := readVerifierKeys(vkPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	var manifest c2pa.Manifest
+	if json.Valid(data) {
+		manifest, err = c2pa.ParseJSON(data)
+	} else {
+		manifest, err = c2pa.ParseCBOR(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(pixels)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, err := image.FromPNG(f)
+	if err != nil {
+		return err
+	}
+
+	photo, err := c2pa.Import(manifest, img, photoproof.ProverKeys{}, verifier)
+	if err != nil {
+		return err
+	}
+	return writeBundle(out, photo)
+}
+
+/*-----------------------------------------------------Services--------------------------------------------------*/
+
+func serveVerify(ctx context.Context, addr string, maxBytes int64, revocations string, window uint64, vkPaths []string) error {
+	if len(vkPaths) == 0 {
+		return fmt.Errorf("%w: serve-verify requires at least one verifier keys file", errUsage)
+	}
+
+	verifier := service.Verifier{Max_Bytes: maxBytes}
+	for _, path := range vkPaths {
+		vk, err := readVerifierKeys(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := readRevocations(revocations, window, &vk); err != nil {
+			return err
+		}
+		verifier.Trusted = append(verifier.Trusted, vk)
+	}
+
+	return serve(ctx, addr, verifier.Handler())
+}
+
+func serveEdit(ctx context.Context, addr string, keys string, key string, secrets secretKeys, editor *service.Editor) error {
+	if key == "" {
+		return fmt.Errorf("%w: serve-edit requires the editor's -key", errUsage)
+	}
+
+	var err error
+	if editor.Prover, editor.Verifier, err = readKeys(keys); err != nil {
+		return err
+	}
+	if editor.User, err = editorKey(key, secrets); err != nil {
+		return err
+	}
+	if err := editor.Start(ctx); err != nil {
+		return err
+	}
+
+	return serve(ctx, addr, editor.Handler())
+}
+
+// Serve handler on addr until ctx is cancelled
+func serve(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+	fmt.Fprintln(os.Stderr, "listening on "+addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdown)
+	}
+}
+
+/*------------------------------------------------------Files----------------------------------------------------*/
+
+func readKeys(dir string) (photoproof.ProverKeys, photoproof.VerifierKeys, error) {
+	f, err := os.Open(filepath.Join(dir, proverFile))
+	if err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, err
+	}
+	defer f.Close()
+
+	prover, err := photoproof.ReadProverKeys(f)
+	if err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, err
+	}
+
+	verifier, err := readVerifierKeys(filepath.Join(dir, verifierFile))
+	if err != nil {
+		return photoproof.ProverKeys{}, photoproof.VerifierKeys{}, err
+	}
+
+	return prover, verifier, nil
+}
+
+func readVerifierKeys(path string) (photoproof.VerifierKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return photoproof.VerifierKeys{}, err
+	}
+	defer f.Close()
+
+	return photoproof.ReadVerifierKeys(f)
+}
+
+// Replace the verifier keys' revocation list with the one published at path, unless path is empty,
+// and accept proofs made against up to window epochs before the latest
+func readRevocations(path string, window uint64, vk *photoproof.VerifierKeys) error {
+	vk.Revocation_Window = window
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	list, err := photoproof.ReadRevocationList(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	vk.Revocations = list
+	return nil
+}
+
+// Read the custody log at path, which must end with the photograph's last step
+func readCustodyLog(path string, photo photoproof.Photograph) (*photoproof.Custody_Log, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	log, err := photoproof.ReadCustodyLog(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(log.Entries) == 0 || !bytes.Equal(log.Entries[len(log.Entries)-1].Commitment, image.ImageCommitment(photo.Z.Img)) {
+		return nil, fmt.Errorf("%w: %s does not end with the input photograph", photoproof.ErrImageMismatch, path)
+	}
+	return log, nil
+}
+
+// Read a bundle file, or a PNG with an embedded bundle
+// Returns the capture counter kept in path, 0 if the file does not exist yet
+func readCounter(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	counter, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return counter, nil
+}
+
+func readBundle(path string) (photoproof.Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return photoproof.Bundle{}, err
+	}
+
+	if photoproof.IsPNG(data) {
+		return photoproof.ReadPNG(bytes.NewReader(data))
+	}
+	return photoproof.ReadBundle(bytes.NewReader(data))
+}
+
+func readPhotograph(path string, prover photoproof.ProverKeys, verifier photoproof.VerifierKeys) (photoproof.Photograph, error) {
+	bundle, err := readBundle(path)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+	return bundle.Photograph(prover, verifier)
+}
+
+// Write the photograph's bundle, embedded in a PNG if path ends with .png
+func writeBundle(path string, photo photoproof.Photograph) error {
+	bundle, err := photo.Bundle()
+	if err != nil {
+		return err
+	}
+	return writeFile(path, bundleFile{bundle, filepath.Ext(path) == ".png"})
+}
+
+// A bundle written as JSON, or embedded in a PNG
+// Returns a new Admin, whose key is held by the signing daemon listening on signer if it is set
+func newAdmin(signer string) (photoproof.User, error) {
+	if signer == "" {
+		return photoproof.NewUser()
+	}
+	client, err := signerd.Dial(signer)
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	return photoproof.NewUserWithSigner(client), nil
+}
+
+// Returns the Admin of the keys, whose key is held by the signing daemon listening on signer if it is set
+func readAdmin(keys string, signer string, secrets secretKeys) (photoproof.User, error) {
+	if signer == "" {
+		return secrets.read(secrets.camera(keys))
+	}
+	return newAdmin(signer)
+}
+
+type bundleFile struct {
+	bundle photoproof.Bundle
+	png    bool
+}
+
+func (f bundleFile) WriteTo(w io.Writer) (int64, error) {
+	if f.png {
+		return 0, f.bundle.WritePNG(w)
+	}
+	return f.bundle.WriteTo(w)
+}
+
+// Where secret keys are kept: hex files named by their path, or the keys of a keystore named by their name
+type secretKeys struct {
+	keystore string
+}
+
+// Returns the name of the camera's key, for the Admin's keys directory
+func (s secretKeys) camera(dir string) string {
+	if s.keystore != "" {
+		return cameraKey
+	}
+	return filepath.Join(dir, cameraFile)
+}
+
+func (s secretKeys) exists(name string) bool {
+	if s.keystore != "" {
+		return keystore.Keystore{Dir: s.keystore}.Has(name)
+	}
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+func (s secretKeys) read(name string) (photoproof.User, error) {
+	if s.keystore == "" {
+		return readUser(name)
+	}
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	return keystore.Keystore{Dir: s.keystore}.Load(name, passphrase)
+}
+
+func (s secretKeys) write(name string, user photoproof.User) error {
+	if s.keystore == "" {
+		return writeUser(name, user)
+	}
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return err
+	}
+	ks, err := keystore.Open(s.keystore)
+	if err != nil {
+		return err
+	}
+	return ks.Save(name, user, passphrase)
+}
+
+func (s secretKeys) passphrase() ([]byte, error) {
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: -keystore requires the passphrase in $%s", errUsage, passphraseEnv)
+	}
+	return []byte(passphrase), nil
+}
+
+// Secret keys are stored hex encoded, readable by their owner only
+func readUser(path string) (photoproof.User, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	secret, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return photoproof.User{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return photoproof.NewUserFromBytes(secret)
+}
+
+func writeUser(path string, user photoproof.User) error {
+	secret, err := user.SecretKeyBytes()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(hex.EncodeToString(secret)+"\n"), 0o600)
+}
+
+func writeFile(path string, v io.WriterTo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := v.WriteTo(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writeJSON(w io.Writer, v any) (int64, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
@@ -0,0 +1,70 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Example of backfilling an archive: the camera signs nb_photos images, its Admin proves their originality
+// in a batch, then an editor applies the identity transformation to all of them in a second batch.
+func Batch_Example(nb_photos int, workers int) ([]photoproof.Photograph, error) {
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Public, photoproof.DefaultPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign every image, proofs are left to the batch prover
+	jobs := make([]photoproof.Job, nb_photos)
+	for i := range jobs {
+		img, err := image.NewImage("random")
+		if err != nil {
+			return nil, err
+		}
+
+		photo, err := cam.NewPhotograph(img)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs[i] = photoproof.Job{
+			Id:     "photo-" + strconv.Itoa(i),
+			Photo:  photo,
+			Tr:     photoproof.Identity_Tr{},
+			Params: photoproof.Identity_Tr_Params{},
+		}
+	}
+
+	// Case 1: prove originality as the camera's Admin
+	originals := photoproof.BatchProver{User: cam.Admin, Workers: workers}.ProveAll(ctx, jobs)
+	for i, result := range originals {
+		if result.Err != nil {
+			fmt.Println("Error while proving " + result.Id + "\n" + result.Err.Error())
+			return nil, result.Err
+		}
+		jobs[i].Photo = result.Photo
+	}
+
+	// Case 2: edit every photograph as a single editor
+	editor, err := photoproof.NewUser()
+	if err != nil {
+		return nil, err
+	}
+	edits := photoproof.BatchProver{User: editor, Workers: workers}.ProveAll(ctx, jobs)
+
+	photos := make([]photoproof.Photograph, len(edits))
+	for i, result := range edits {
+		if result.Err != nil {
+			fmt.Println("Error while editing " + result.Id + "\n" + result.Err.Error())
+			return nil, result.Err
+		}
+		photos[i] = result.Photo
+	}
+
+	return photos, nil
+}
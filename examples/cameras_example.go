@@ -0,0 +1,44 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Example of a newsroom registering a second camera under one setup, whose photographs do not reveal
+// which of the newsroom's cameras took them
+func Cameras_Example() (photoproof.Verdict, error) {
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Private|photoproof.Mode_Hidden_Camera, photoproof.DefaultPolicy())
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	// The second camera signs with its own key, which the Admin authorises
+	user, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	device, err := cam.NewDevice(user)
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	photo, err := device.TakePhotograph(ctx, "random")
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	// Publish the photograph without the camera's key, it verifies against the root of the authorised cameras
+	bundle, err := photo.Bundle()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	verdict := photoproof.VerifyBundle(bundle.HideCamera(), cam.VerifyingKey)
+	fmt.Println("valid:", verdict.Valid, "cameras root:", verdict.Cameras_Root, "camera:", verdict.Camera)
+
+	return verdict, verdict.Err
+}
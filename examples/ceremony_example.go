@@ -0,0 +1,80 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Example of a trusted setup ceremony with nb_contributors participants per phase.
+// Every contribution goes through a file in a temporary directory, exactly as if each participant
+// ran in their own process, then the resulting keys are used to take a photograph.
+func Ceremony_Example(nb_contributors int) (photoproof.Photograph, error) {
+	dir, err := os.MkdirTemp("", "photognark-ceremony")
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := func(name string, i int) string {
+		return filepath.Join(dir, name+"_"+strconv.Itoa(i))
+	}
+
+	ceremony, err := camera.NewCeremony(photoproof.NewCircuit(photoproof.Mode_Public, photoproof.DefaultPolicy()))
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	// Phase 1: circuit-independent powers of tau
+	if err := ceremony.Phase1_Init(path("phase1", 0)); err != nil {
+		return photoproof.Photograph{}, err
+	}
+	phase1 := []string{}
+	for i := 1; i <= nb_contributors; i++ {
+		if err := camera.Phase1_Contribute(path("phase1", i-1), path("phase1", i)); err != nil {
+			return photoproof.Photograph{}, err
+		}
+		phase1 = append(phase1, path("phase1", i))
+	}
+
+	commons := filepath.Join(dir, "commons")
+	if err := ceremony.Phase1_Verify([]byte("phase 1 beacon"), commons, phase1...); err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	// Phase 2: specific to the PhotoGnark circuit
+	if err := ceremony.Phase2_Init(commons, path("phase2", 0)); err != nil {
+		return photoproof.Photograph{}, err
+	}
+	phase2 := []string{}
+	for i := 1; i <= nb_contributors; i++ {
+		if err := camera.Phase2_Contribute(path("phase2", i-1), path("phase2", i)); err != nil {
+			return photoproof.Photograph{}, err
+		}
+		phase2 = append(phase2, path("phase2", i))
+	}
+
+	provingKey, verifyingKey, err := ceremony.Finalize(commons, []byte("phase 2 beacon"), phase2...)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	admin, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	cam := camera.NewCameraFromKeys(admin, photoproof.Mode_Public, photoproof.DefaultPolicy(), provingKey, verifyingKey)
+	photo, err := cam.TakePhotograph(context.Background(), "random")
+	if err != nil {
+		fmt.Println("Error while taking a photograph with the ceremony keys\n" + err.Error())
+		return photoproof.Photograph{}, err
+	}
+
+	return photo, nil
+}
@@ -0,0 +1,47 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Example of a newsroom whose editors stay anonymous: the edited photograph only proves that one of the
+// newsroom's authorised editors signed it
+func Editors_Example() (photoproof.Verdict, error) {
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Private|photoproof.Mode_Hidden_Editor, photoproof.DefaultPolicy())
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	// The Admin authorises the editor's key
+	editor, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	if err := cam.AuthoriseEditor(editor.PublicKey); err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	photo, err := cam.TakePhotograph(ctx, "random")
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	edited, err := editor.Edit(ctx, photo, photoproof.Identity_Tr{}, photoproof.Identity_Tr_Params{})
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	// The bundle leaves out the editor's key and signature, it verifies against the root of the authorised editors
+	bundle, err := edited.Bundle()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	verdict := photoproof.VerifyBundle(bundle, cam.VerifyingKey)
+	fmt.Println("valid:", verdict.Valid, "editors root:", verdict.Editors_Root, "editor:", verdict.Editor)
+
+	return verdict, verdict.Err
+}
@@ -72,15 +72,18 @@ func Example_1_Prover(pr_k Test_ProverKeys) (groth16.Proof, []byte, signature.Pu
 		return nil, nil, nil, nil, err
 	}
 
-	prover := photoproof.NewUser()
+	prover, err := photoproof.NewUser()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 
 	fmt.Println(img)
 
 	/* Sign the image */
 	digest := image.ImageHash(img) // Use ToBytes as hash
 
-	hFunc := hash.MIMC_BN254.New()                         // Instantiate MIMC BN254 hash function
-	signature, err := prover.SecretKey.Sign(digest, hFunc) // Sign the digest's bytes with the hash function
+	hFunc := hash.MIMC_BN254.New()                      // Instantiate MIMC BN254 hash function
+	signature, err := prover.Signer.Sign(digest, hFunc) // Sign the digest's bytes with the hash function
 	if err != nil {
 		fmt.Println("Error while signing image: " + err.Error())
 		return nil, nil, nil, nil, err
@@ -1,20 +1,40 @@
 package examples
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/drakstik/PhotoGnark_ACDF/camera"
 	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
 )
 
-func NewCamera_Example() camera.Camera {
-	return camera.NewCamera() // Get a new camera, run generator
+// Example of an Observer printing how long each phase took
+func PrintProgress(progress photoproof.Progress) {
+	if !progress.Finished {
+		return
+	}
+	if progress.Err != nil {
+		fmt.Println("[" + string(progress.Phase) + "] failed after " + progress.Elapsed.String() + ": " + progress.Err.Error())
+		return
+	}
+	fmt.Println("[" + string(progress.Phase) + "] took " + progress.Elapsed.String())
+}
+
+func NewCamera_Example() (camera.Camera, error) {
+	ctx := photoproof.WithObserver(context.Background(), PrintProgress)
+	return camera.NewCamera(ctx, photoproof.Mode_Public, photoproof.DefaultPolicy()) // Get a new camera, run generator
 }
 
 // Example for a new camera and taking a photo
 func TakePhoto_Example() (photoproof.Photograph, error) {
-	cam := NewCamera_Example()                 // Get a new camera, run generator
-	photo, err := cam.TakePhotograph("random") // Take a picture and prove it
+	ctx := photoproof.WithObserver(context.Background(), PrintProgress)
+	cam, err := NewCamera_Example() // Get a new camera, run generator
+	if err != nil {
+		fmt.Println("Error while creating a camera\n" + err.Error())
+		return photoproof.Photograph{}, err
+	}
+
+	photo, err := cam.TakePhotograph(ctx, "random") // Take a picture and prove it
 	if err != nil {
 		fmt.Println("Error while taking a photograph\n" + err.Error())
 		return photoproof.Photograph{}, err
@@ -23,9 +43,57 @@ func TakePhoto_Example() (photoproof.Photograph, error) {
 	return photo, err
 }
 
-func EditPhoto_Example(photo photoproof.Photograph, tr photoproof.Transformation, params photoproof.Parameters) {
+func EditPhoto_Example(photo photoproof.Photograph, tr photoproof.Transformation, params photoproof.Parameters) (photoproof.Photograph, error) {
+
+	ctx := photoproof.WithObserver(context.Background(), PrintProgress)
+	editor, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	return editor.Edit(ctx, photo, tr, params)
+}
+
+// Example of a verifier checking a photograph against the Admin's verifier keys
+func VerifyPhoto_Example(photo photoproof.Photograph, vk photoproof.VerifierKeys) bool {
+	ok, err := photoproof.Verify(photo, vk)
+	if err != nil {
+		fmt.Println("Error while verifying a photograph\n" + err.Error())
+		return false
+	}
+
+	fmt.Println("********Verifying photograph was successful!********")
+
+	// Decode the verified provenance with the policy of the trusted verifier keys
+	report, err := photoproof.NewReport(photo.Z.Img, vk.Policy)
+	if err != nil {
+		fmt.Println("Error while decoding the provenance\n" + err.Error())
+		return false
+	}
+	fmt.Print(report)
+
+	return ok
+}
+
+// Example of a camera whose photographs only reveal a commitment to their image in the proof's public inputs
+func PrivatePhoto_Example() (photoproof.Photograph, error) {
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Private, photoproof.DefaultPolicy()) // Get a new camera, run generator for PhotoGnark_Private
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+	photo, err := cam.TakePhotograph(ctx, "random")
+	if err != nil {
+		fmt.Println("Error while taking a photograph\n" + err.Error())
+		return photoproof.Photograph{}, err
+	}
+
+	edited, err := EditPhoto_Example(photo, photoproof.Identity_Tr{}, photoproof.Identity_Tr_Params{})
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
 
-	editor := photoproof.NewUser()
+	VerifyPhoto_Example(edited, cam.VerifyingKey)
 
-	editor.Edit(photo, tr, params)
+	return edited, nil
 }
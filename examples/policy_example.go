@@ -0,0 +1,45 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// A forensic policy: photographs may be re-proven by editors, but never altered.
+const forensicPolicy = `{
+	"name": "forensic",
+	"transformations": [
+		{ "name": "identity", "bound": 1 }
+	]
+}`
+
+// Example of an Admin setting up a camera for a policy file, which sets the provenance of every photograph it takes
+func Policy_Example() (photoproof.Photograph, error) {
+	policy, err := photoproof.ParsePolicy([]byte(forensicPolicy))
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Public, policy)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	photo, err := cam.TakePhotograph(ctx, "random")
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+
+	// Decode the provenance set by the policy
+	report, err := photoproof.NewReport(photo.Z.Img, cam.VerifyingKey.Policy)
+	if err != nil {
+		return photoproof.Photograph{}, err
+	}
+	fmt.Print(report)
+
+	return photo, nil
+}
@@ -0,0 +1,94 @@
+package examples
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+/*
+[Gnark-circuit] Check an image hash and its signature in-circuit, with the given hash function
+
+	Secret values: Img, Signature, PublicKey
+	Public values: Digest
+*/
+type Hash_Circuit struct {
+	Img       image.Fr_Image    `gnark:",secret"`
+	Digest    frontend.Variable `gnark:",public"`
+	Signature eddsa.Signature   `gnark:",secret"`
+	PublicKey eddsa.PublicKey   `gnark:",secret"`
+
+	Hash image.Hash `gnark:"-"`
+}
+
+func (circuit *Hash_Circuit) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.Hash.Fr_ImageHash(api, circuit.Img), circuit.Digest)
+	photoproof.Verify_Signature(api, circuit.Hash, circuit.Digest, circuit.Signature, circuit.PublicKey)
+	return nil
+}
+
+// Example checking that both sides of every hash function agree: the in-circuit digests match the out-of-circuit
+// ones, and a signature made out-of-circuit verifies in-circuit. The test vectors are in image/hash_test.go.
+// Prints the constraint counts of the hash circuit and of the PhotoGnark circuit for each hash function.
+func Poseidon2_Example() error {
+	signer, err := photoproof.NewUser()
+	if err != nil {
+		return err
+	}
+	var public_key eddsa.PublicKey
+	public_key.Assign(tedwards.BN254, signer.PublicKey.Bytes())
+
+	for _, h := range []image.Hash{image.Hash_MiMC, image.Hash_Poseidon2} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &Hash_Circuit{Hash: h})
+		if err != nil {
+			return err
+		}
+
+		for _, flag := range []string{"white", "black"} {
+			img, err := image.NewImage(flag)
+			if err != nil {
+				return err
+			}
+
+			// Out-of-circuit
+			digest := h.ImageHash(img)
+			sig, err := signer.Sign(h, img)
+			if err != nil {
+				return err
+			}
+			if err := photoproof.VerifySignature(h, signer.PublicKey, digest, sig); err != nil {
+				return err
+			}
+
+			// In-circuit
+			var signature eddsa.Signature
+			signature.Assign(tedwards.BN254, sig)
+			assignment := Hash_Circuit{Img: image.ImageToFr(img), Digest: digest, Signature: signature, PublicKey: public_key}
+			witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+			if err != nil {
+				return err
+			}
+			if err := ccs.IsSolved(witness); err != nil {
+				return fmt.Errorf("%s in-circuit digest of the %s image: %w", h, flag, err)
+			}
+		}
+
+		mode := photoproof.Mode_Public
+		if h == image.Hash_Poseidon2 {
+			mode |= photoproof.Mode_Poseidon2
+		}
+		photognark, err := photoproof.Compile(mode, photoproof.DefaultPolicy())
+		if err != nil {
+			return err
+		}
+		fmt.Println(h, "agrees in and out of circuit, hash & signature:", ccs.GetNbConstraints(), "constraints, PhotoGnark:", photognark.GetNbConstraints(), "constraints")
+	}
+
+	return nil
+}
@@ -83,13 +83,16 @@ func Example_2_Admin() (Test_ProverKeys, Test_VerifierKeys, error) {
 func Example_2_Prover(pr_k Test_ProverKeys) (groth16.Proof, signature.PublicKey, []byte, []byte, error) {
 	/* Create a new image and user */
 	img, _ := image.NewImage("random")
-	prover := photoproof.NewUser()
+	prover, err := photoproof.NewUser()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 
 	/* Sign the image */
 	digest := image.ImageHash(img) // Use ToBytes as hash
 
-	hFunc := hash.MIMC_BN254.New()                         // Instantiate MIMC BN254 hash function
-	signature, err := prover.SecretKey.Sign(digest, hFunc) // Sign the digest's bytes with the hash function
+	hFunc := hash.MIMC_BN254.New()                      // Instantiate MIMC BN254 hash function
+	signature, err := prover.Signer.Sign(digest, hFunc) // Sign the digest's bytes with the hash function
 	if err != nil {
 		fmt.Println("Error while signing image: " + err.Error())
 		return nil, nil, nil, nil, err
@@ -137,13 +140,16 @@ func Example_2_Prover(pr_k Test_ProverKeys) (groth16.Proof, signature.PublicKey,
 
 func Example_2_Verifier(proof groth16.Proof, pk signature.PublicKey, vk Test_VerifierKeys, digest []byte) (bool, error) {
 	dummy_image, _ := image.NewImage("random")
-	viewer := photoproof.NewUser()
+	viewer, err := photoproof.NewUser()
+	if err != nil {
+		return false, err
+	}
 
 	/* Sign the image */
 	dummy_digest := image.ImageHash(dummy_image) // Use ToBytes as hash
 
-	hFunc := hash.MIMC_BN254.New()                               // Instantiate MIMC BN254 hash function
-	signature, err := viewer.SecretKey.Sign(dummy_digest, hFunc) // Sign the digest's bytes with the hash function
+	hFunc := hash.MIMC_BN254.New()                            // Instantiate MIMC BN254 hash function
+	signature, err := viewer.Signer.Sign(dummy_digest, hFunc) // Sign the digest's bytes with the hash function
 	if err != nil {
 		fmt.Println("Error while signing image: " + err.Error())
 		return false, err
@@ -0,0 +1,95 @@
+package examples
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*
+[Gnark-circuit] Prove that an image differs from another in a single tile, without either image in the circuit:
+only the changed tile, before and after, and its Merkle path.
+
+	Secret values: Tile_in, Tile_out, Path
+	Public values: Root_in, Root_out (see image.TileRoot())
+*/
+type Tile_Update_Circuit struct {
+	Root_in  frontend.Variable           `gnark:",public"`
+	Root_out frontend.Variable           `gnark:",public"`
+	Tile_in  [image.Tile2]image.Fr_Pixel `gnark:",secret"`
+	Tile_out [image.Tile2]image.Fr_Pixel `gnark:",secret"`
+	Path     image.Fr_Tile_Path          `gnark:",secret"`
+}
+
+func (circuit *Tile_Update_Circuit) Define(api frontend.API) error {
+	for _, px := range circuit.Tile_out {
+		for _, v := range px.RGB {
+			api.ToBinary(v, 8)
+		}
+	}
+
+	leaf_in := image.Fr_TileLeaf(api, circuit.Tile_in)
+	leaf_out := image.Fr_TileLeaf(api, circuit.Tile_out)
+	api.AssertIsEqual(image.Fr_UpdateTile(api, circuit.Root_in, leaf_in, leaf_out, circuit.Path), circuit.Root_out)
+	return nil
+}
+
+// Example of an edit of a single tile, proven with the tile's Merkle path instead of the whole image
+func Tiles_Example() (bool, error) {
+	img_in, err := image.NewImage("random")
+	if err != nil {
+		return false, err
+	}
+
+	// Change one pixel, so a single tile changes
+	img_out := img_in
+	img_out.Pxls[image.To_1D_Index(image.N-1, image.N-1)].RGB[0] ^= 0xff
+	changed := image.ChangedTiles(img_in, img_out)
+	if len(changed) != 1 {
+		return false, fmt.Errorf("%d tiles changed, expected 1", len(changed))
+	}
+	path, err := image.TilePath(img_in, changed[0])
+	if err != nil {
+		return false, err
+	}
+
+	assignment := Tile_Update_Circuit{
+		Root_in:  image.TileRoot(img_in),
+		Root_out: image.TileRoot(img_out),
+		Tile_in:  image.TileToFr(img_in.Tile(changed[0])),
+		Tile_out: image.TileToFr(img_out.Tile(changed[0])),
+		Path:     path.ToFr(),
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &Tile_Update_Circuit{})
+	if err != nil {
+		return false, err
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return false, err
+	}
+
+	witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return false, err
+	}
+	proof, err := groth16.Prove(ccs, pk, witness)
+	if err != nil {
+		return false, err
+	}
+	public_witness, err := witness.Public()
+	if err != nil {
+		return false, err
+	}
+	if err := groth16.Verify(proof, vk, public_witness); err != nil {
+		return false, err
+	}
+
+	fmt.Println("tile", changed[0], "of", image.Tiles, "changed, proven with", ccs.GetNbConstraints(), "constraints")
+	return true, nil
+}
@@ -0,0 +1,49 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+	"github.com/drakstik/PhotoGnark_ACDF/tsa"
+)
+
+// Example of a camera whose capture time is set by a timestamp authority, and numbered by its capture counter.
+// The capture is carried unchanged through every edit.
+func Timestamp_Example() (photoproof.Verdict, error) {
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Private, photoproof.DefaultPolicy())
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	authority, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	cam.Timestamper = tsa.New(authority)
+	cam.Use_Counter = true
+
+	photo, err := cam.TakePhotograph(ctx, "random")
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	editor, err := photoproof.NewUser()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	edited, err := editor.Edit(ctx, photo, photoproof.Identity_Tr{}, photoproof.Identity_Tr_Params{})
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+
+	bundle, err := edited.Bundle()
+	if err != nil {
+		return photoproof.Verdict{}, err
+	}
+	verdict := photoproof.VerifyBundle(bundle, cam.VerifyingKey)
+	fmt.Println("valid:", verdict.Valid, "captured:", verdict.Captured, "counter:", verdict.Counter, "authority:", verdict.Timestamp_Authority)
+
+	return verdict, verdict.Err
+}
@@ -0,0 +1,87 @@
+package image
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+/*----------------------------------------------- Areas -------------------------------------*/
+
+// Returns the area covering the whole image
+func Whole_Area() Area {
+	return Area{Loc: PixelLocation{X: 0, Y: 0}, Width: N, Height: N}
+}
+
+// Returns an error if the area is empty or does not fit in the image
+func (area Area) Validate() error {
+	if area.Width == 0 || area.Height == 0 {
+		return fmt.Errorf("image: area %dx%d is empty", area.Width, area.Height)
+	}
+	if area.Loc.X >= N || area.Loc.Y >= N || area.Width > N-area.Loc.X || area.Height > N-area.Loc.Y {
+		return fmt.Errorf("image: area %dx%d at (%d, %d) does not fit in a %dx%d image", area.Width, area.Height, area.Loc.X, area.Loc.Y, N, N)
+	}
+	return nil
+}
+
+// Returns true if the pixel at (x, y) is within the area
+func (area Area) Contains(x uint64, y uint64) bool {
+	return x >= area.Loc.X && x-area.Loc.X < area.Width && y >= area.Loc.Y && y-area.Loc.Y < area.Height
+}
+
+// Returns true if both images have the same pixels within the area
+func (area Area) Equal(a Image, b Image) bool {
+	for y := uint64(0); y < N; y++ {
+		for x := uint64(0); x < N; x++ {
+			if area.Contains(x, y) && a.Pxls[To_1D_Index(x, y)].RGB != b.Pxls[To_1D_Index(x, y)].RGB {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (area Area) ToFr() Fr_Area {
+	return Fr_Area{
+		Loc:    Fr_PixelLocation{X: area.Loc.X, Y: area.Loc.Y},
+		Width:  area.Width,
+		Height: area.Height,
+	}
+}
+
+/*------------------------------------------ Gnark-Friendly Areas --------------------------------------*/
+
+// [In-Circuit] Returns one variable per pixel, indexed like Fr_Image.Pxls: 1 if the pixel is within the area,
+// 0 otherwise. Asserts that the area is not empty and fits in the image, mirroring Area.Validate().
+func (area Fr_Area) Mask(api frontend.API) [N2]frontend.Variable {
+	api.AssertIsDifferent(area.Width, 0)
+	api.AssertIsDifferent(area.Height, 0)
+	api.AssertIsLessOrEqual(area.Loc.X, N)
+	api.AssertIsLessOrEqual(area.Loc.Y, N)
+	api.AssertIsLessOrEqual(area.Width, N)
+	api.AssertIsLessOrEqual(area.Height, N)
+	api.AssertIsLessOrEqual(api.Add(area.Loc.X, area.Width), N)
+	api.AssertIsLessOrEqual(api.Add(area.Loc.Y, area.Height), N)
+
+	// Every column & row is compared once, then combined per pixel
+	var cols, rows [N]frontend.Variable
+	for i := uint64(0); i < N; i++ {
+		cols[i] = fr_within(api, i, area.Loc.X, api.Add(area.Loc.X, area.Width))
+		rows[i] = fr_within(api, i, area.Loc.Y, api.Add(area.Loc.Y, area.Height))
+	}
+
+	var mask [N2]frontend.Variable
+	for y := uint64(0); y < N; y++ {
+		for x := uint64(0); x < N; x++ {
+			mask[To_1D_Index(x, y)] = api.Mul(cols[x], rows[y])
+		}
+	}
+	return mask
+}
+
+// Returns 1 if lo <= v < hi, 0 otherwise
+func fr_within(api frontend.API, v uint64, lo frontend.Variable, hi frontend.Variable) frontend.Variable {
+	from_lo := api.Sub(1, api.IsZero(api.Sub(api.Cmp(lo, v), 1))) // not lo > v
+	to_hi := api.IsZero(api.Add(api.Cmp(v, hi), 1))               // v < hi
+	return api.Mul(from_lo, to_hi)
+}
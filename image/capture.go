@@ -0,0 +1,90 @@
+package image
+
+import (
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	out_mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+/*------------------------------------------- Capture Metadata ------------------------------------------*/
+
+/*
+	The camera signs when it took the image along with its pixels. The original hash is computed in two steps,
+	so that a timestamp authority can set the time of a digest it is sent, as in RFC 3161:
+
+		capture digest = MiMC(PxlBytes, MetadataDigest, Counter)
+		original hash  = MiMC(capture digest, Time)
+*/
+
+// When an image was captured. It is hashed into the original hash and carried unchanged through every edit.
+type Capture struct {
+	Time    uint64 `json:"time"`              // Unix time of the capture, in seconds
+	Counter uint64 `json:"counter,omitempty"` // The camera's monotonic capture counter, 0 if it does not count
+}
+
+// An RFC 3161-style token: a timestamp authority's signature of an original hash and the time it set
+type Timestamp_Token struct {
+	Time      uint64 `json:"time"`      // Same as Capture.Time
+	Serial    uint64 `json:"serial"`    // Unique per token of the authority
+	Authority []byte `json:"authority"` // Compressed EdDSA BN254 public key of the authority
+	Signature []byte `json:"signature"`
+}
+
+// Returns the digest of the image, its metadata and capture counter, which is sent to a timestamp authority
+func CaptureDigest(img Image, counter uint64) []byte {
+	h := out_mimc.NewMiMC()
+	h.Write(img.PxlBytes)
+	h.Write(MetadataDigest(img.Metadata))
+	h.Write(uint64Bytes(counter))
+	return h.Sum(nil)
+}
+
+// Returns the original hash of a capture digest taken at time
+func OriginalHash(capture_digest []byte, time uint64) []byte {
+	h := out_mimc.NewMiMC()
+	h.Write(capture_digest)
+	h.Write(uint64Bytes(time))
+	return h.Sum(nil)
+}
+
+// Returns the original hash of the image captured at capture, which the camera signs
+func CaptureHash(img Image, capture Capture) []byte {
+	return OriginalHash(CaptureDigest(img, capture.Counter), capture.Time)
+}
+
+// Returns the digest a timestamp authority signs for the token with the given serial: MiMC(original hash, serial)
+func TimestampDigest(original_hash []byte, serial uint64) []byte {
+	h := out_mimc.NewMiMC()
+	h.Write(original_hash)
+	h.Write(uint64Bytes(serial))
+	return h.Sum(nil)
+}
+
+func uint64Bytes(v uint64) []byte {
+	var fe fr.Element
+	fe.SetUint64(v)
+	return fe.Marshal()
+}
+
+/*------------------------------------------ Gnark-Friendly Capture --------------------------------------*/
+
+type Fr_Capture struct {
+	Time    frontend.Variable
+	Counter frontend.Variable
+}
+
+func (capture Capture) ToFr() Fr_Capture {
+	return Fr_Capture{Time: capture.Time, Counter: capture.Counter}
+}
+
+// [In-Circuit] Returns the original hash of the image captured at capture, mirroring CaptureHash()
+func Fr_CaptureHash(api frontend.API, img Fr_Image, capture Fr_Capture) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+	h.Write(append(img.PxlBytes[:], Fr_MetadataDigest(api, img.Metadata), capture.Counter)...)
+	digest := h.Sum()
+
+	h.Reset()
+	h.Write(digest, capture.Time)
+	return h.Sum()
+}
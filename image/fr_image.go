@@ -29,9 +29,10 @@ type Fr_Provenance struct {
 
 // [Gnark-friendly] An image object that can be manipulated by Gnark circuits
 type Fr_Image struct {
-	Pxls       [N2]Fr_Pixel      `gnark:",inherit"`
-	PxlBytes   frontend.Variable `gnark:",inherit"`
-	Provenance [P]Fr_Provenance  `gnark:",inherit"`
+	Pxls       [N2]Fr_Pixel                `gnark:",inherit"`
+	PxlBytes   [Nb_Limbs]frontend.Variable `gnark:",inherit"` // One field element per limb, see BigInt_to_Fr_Bytes()
+	Provenance [P]Fr_Provenance            `gnark:",inherit"`
+	Metadata   Fr_Metadata                 `gnark:",inherit"`
 }
 
 /*------------------------------------------ Gnark-Friendly Area --------------------------------------*/
@@ -0,0 +1,109 @@
+package image
+
+import (
+	"fmt"
+	stdhash "hash"
+	"reflect"
+
+	_ "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	out_poseidon2 "github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon2"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	fr_hash "github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/permutation/poseidon2"
+)
+
+/*------------------------------------------- Hash Functions ------------------------------------------*/
+
+/*
+	The hash function of image hashes and signatures is chosen by the Admin at setup (see photoproof.Mode), so
+	both sides of the circuit must agree on it: Hash.ImageHash() mirrors Hash.Fr_ImageHash(), and a digest
+	signed with Hash.New() verifies in-circuit with Hash.Fr_New().
+
+	Only image hashes and signatures take a Hash. The capture hash (CaptureHash()), image and tile commitments
+	(ImageCommitment(), TileCommitment()), the metadata digest and photoproof's Merkle trees, timestamp tokens and
+	custody logs are hardcoded to MiMC, so Hash_Poseidon2 does not remove MiMC from the circuits.
+*/
+
+// A hash function over the BN254 scalar field, with out-of-circuit and in-circuit implementations.
+// It is the hash function of image hashes and signatures only, see above.
+type Hash uint8
+
+const (
+	Hash_MiMC      Hash = 0 // MiMC BN254, the default
+	Hash_Poseidon2 Hash = 1 // Poseidon2 BN254, in Merkle-Damgård mode
+)
+
+func (h Hash) String() string {
+	switch h {
+	case Hash_MiMC:
+		return "mimc"
+	case Hash_Poseidon2:
+		return "poseidon2"
+	}
+	return fmt.Sprintf("hash(%d)", uint8(h))
+}
+
+// Returns the hash function named by String()
+func ParseHash(name string) (Hash, error) {
+	for _, h := range []Hash{Hash_MiMC, Hash_Poseidon2} {
+		if h.String() == name {
+			return h, nil
+		}
+	}
+	return 0, fmt.Errorf("image: unknown hash function %q", name)
+}
+
+// Returns a new out-of-circuit instance of the hash function
+func (h Hash) New() stdhash.Hash {
+	if h == Hash_Poseidon2 {
+		return hash.POSEIDON2_BN254.New()
+	}
+	return hash.MIMC_BN254.New()
+}
+
+// Returns the hash function of an instance returned by Hash.New(), e.g. one passed to a signer
+func HashOf(hFunc stdhash.Hash) (Hash, bool) {
+	for _, h := range []Hash{Hash_MiMC, Hash_Poseidon2} {
+		if reflect.TypeOf(hFunc) == reflect.TypeOf(h.New()) {
+			return h, true
+		}
+	}
+	return 0, false
+}
+
+// [In-Circuit] Returns a new instance of the hash function, mirroring Hash.New().
+// Panics if the hash function cannot be built over the circuit's field, which frontend.Compile() reports as an
+// error.
+func (h Hash) Fr_New(api frontend.API) fr_hash.FieldHasher {
+	if h == Hash_Poseidon2 {
+		// gnark has no default BN254 parameters in-circuit, use gnark-crypto's
+		params := out_poseidon2.GetDefaultParameters()
+		permutation, err := poseidon2.NewPoseidon2FromParameters(api, params.Width, params.NbFullRounds, params.NbPartialRounds)
+		if err != nil {
+			panic(fmt.Sprintf("image: in-circuit %s: %v", h, err))
+		}
+		return fr_hash.NewMerkleDamgardHasher(api, permutation, 0)
+	}
+	hasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		panic(fmt.Sprintf("image: in-circuit %s: %v", h, err))
+	}
+	return &hasher
+}
+
+// Return the hash digest of the PxlBytes and metadata of an Image
+func (h Hash) ImageHash(img Image) []byte {
+	hFunc := h.New()
+	hFunc.Write(img.PxlBytes)
+	hFunc.Write(MetadataDigest(img.Metadata))
+	return hFunc.Sum(nil)
+}
+
+// [In-Circuit] Return the hash digest of the PxlBytes and metadata of an Fr_Image, mirroring Hash.ImageHash()
+func (h Hash) Fr_ImageHash(api frontend.API, img Fr_Image) frontend.Variable {
+	hFunc := h.Fr_New(api)
+	hFunc.Write(append(img.PxlBytes[:], Fr_MetadataDigest(api, img.Metadata))...)
+	return hFunc.Sum()
+}
@@ -0,0 +1,88 @@
+package image
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Known-answer vectors of Hash.ImageHash(): the hex encoded digests of an all-white and an all-black image, without
+// provenance or metadata
+var hash_vectors = map[Hash][2]string{
+	Hash_MiMC: {
+		"0069c190b0ea6e78c5ae70b01a9a42644b5fb8d733dda37fef15a3cdfc4c6369",
+		"149935f5f67767f9890a70ac3c487ab9cc432d1d8943d6a7128cba9b557cb4ed",
+	},
+	Hash_Poseidon2: {
+		"0d8bea91b77897c4b3f46c8c71b6b6eea92a20f1c6fdf5479dd25e546384a90a",
+		"0a56c403ac6e8449abb1fbe32914a6756415b031ada5c636fabfe1df8f39592f",
+	},
+}
+
+type imageHashCircuit struct {
+	Img    Fr_Image
+	Digest frontend.Variable `gnark:",public"`
+
+	Hash Hash `gnark:"-"`
+}
+
+func (circuit *imageHashCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.Hash.Fr_ImageHash(api, circuit.Img), circuit.Digest)
+	return nil
+}
+
+func TestHash_ImageHash(t *testing.T) {
+	for h, vectors := range hash_vectors {
+		for i, flag := range []string{"white", "black"} {
+			digest := h.ImageHash(newTestImage(t, flag))
+			if hex.EncodeToString(digest) != vectors[i] {
+				t.Errorf("%s digest of the %s image is %x, expected %s", h, flag, digest, vectors[i])
+			}
+		}
+	}
+}
+
+func TestHash_Fr_ImageHash(t *testing.T) {
+	for _, h := range []Hash{Hash_MiMC, Hash_Poseidon2} {
+		img := newTestImage(t, "random")
+		img.Provenance[0] = Provenance{Tr_Name: 1, Tr_Bound: 2}
+		img.Metadata = Metadata{Device_Serial: "test", GPS: &GPS{Latitude: -33868820, Longitude: 151209290}, GPS_Recorded: true, Exposure: 8000}
+		img.PxlBytes = BigInt_to_Fr_Bytes(img)
+
+		for i, flag := range []string{"white", "black"} {
+			vector, err := hex.DecodeString(hash_vectors[h][i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			assignment := imageHashCircuit{Img: ImageToFr(newTestImage(t, flag)), Digest: vector}
+			if err := solve(t, &imageHashCircuit{Hash: h}, &assignment); err != nil {
+				t.Errorf("%s in-circuit digest of the %s image: %v", h, flag, err)
+			}
+		}
+
+		assignment := imageHashCircuit{Img: ImageToFr(img), Digest: h.ImageHash(img)}
+		if err := solve(t, &imageHashCircuit{Hash: h}, &assignment); err != nil {
+			t.Errorf("%s in-circuit digest: %v", h, err)
+		}
+
+		// The digest of the other hash function
+		other := Hash_MiMC
+		if h == Hash_MiMC {
+			other = Hash_Poseidon2
+		}
+		assignment = imageHashCircuit{Img: ImageToFr(img), Digest: other.ImageHash(img)}
+		if err := solve(t, &imageHashCircuit{Hash: h}, &assignment); err == nil {
+			t.Errorf("%s accepted the %s digest", h, other)
+		}
+
+		// The digest of another image
+		edited := img
+		edited.Pxls[0].RGB[0] ^= 0x01
+		edited.PxlBytes = BigInt_to_Fr_Bytes(edited)
+		assignment = imageHashCircuit{Img: ImageToFr(img), Digest: h.ImageHash(edited)}
+		if err := solve(t, &imageHashCircuit{Hash: h}, &assignment); err == nil {
+			t.Errorf("%s accepted the digest of another image", h)
+		}
+	}
+}
@@ -48,6 +48,9 @@ type Image struct {
 	// so far. This does not reveal the previous pixel values, the params of the transformation, nor the order of
 	// the transformation. Provenance, alongside Pxls, is used to generate PxlBytes.
 	Provenance [P]Provenance
+
+	// Capture metadata, hashed alongside PxlBytes (see ImageHash())
+	Metadata Metadata
 }
 
 /*----------------------------------------------- Area Construction -------------------------------------*/
@@ -0,0 +1,55 @@
+package image
+
+import (
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	out_mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+/* ------------------------------------In-Circuit & Out-of-Circuit Image Commitments-------------------------------- */
+
+/*
+	PxlBytes packs an entire image into Nb_Limbs field elements, whose pixels must all be in the circuit to recompute
+	them. An image commitment instead hashes every pixel and provenance entry as its own field element,
+	which lets a verifier hold a single digest in place of the pixels: the pixels can stay a secret witness
+	while the commitment is a public input, and the verifier recomputes it from the delivered image.
+*/
+
+// Return the MiMC commitment to the pixels, provenance and metadata of an Image
+func ImageCommitment(img Image) []byte {
+	h := out_mimc.NewMiMC()
+
+	var fe fr.Element
+	for _, px := range img.Pxls {
+		fe.SetUint64(uint64(px.RGB[0])<<16 | uint64(px.RGB[1])<<8 | uint64(px.RGB[2]))
+		h.Write(fe.Marshal())
+	}
+
+	for _, prov := range img.Provenance {
+		fe.SetUint64(prov.Tr_Name)
+		h.Write(fe.Marshal())
+		fe.SetUint64(prov.Tr_Bound)
+		h.Write(fe.Marshal())
+	}
+	h.Write(MetadataDigest(img.Metadata))
+
+	return h.Sum(nil)
+}
+
+// Return the MiMC commitment to the pixels, provenance and metadata of an Fr_Image.
+// The pixels are range checked by Fr_PxlBytes(), which must also be applied to img.
+func Fr_ImageCommitment(api frontend.API, img Fr_Image) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+
+	for _, px := range img.Pxls {
+		h.Write(api.Add(api.Mul(px.RGB[0], 1<<16), api.Mul(px.RGB[1], 1<<8), px.RGB[2]))
+	}
+
+	for _, prov := range img.Provenance {
+		h.Write(prov.Tr_Name, prov.Tr_Bound)
+	}
+	h.Write(Fr_MetadataDigest(api, img.Metadata))
+
+	return h.Sum()
+}
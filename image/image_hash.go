@@ -4,9 +4,7 @@ import (
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	out_mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/std/hash/mimc"
 )
 
 /* ----------------------------------------Hashing Utility Functions---------------------------------------- */
@@ -43,30 +41,98 @@ func ImageToBigInt(img Image) *big.Int {
 	return result
 }
 
+// Number of bits packed by ImageToBigInt(): 32 per pixel, 64 for each of Tr_Name & Tr_Bound per provenance entry
+const Pxl_Bits = int(N2*32 + P*128)
+
+// Number of bits of a limb of PxlBytes, so every limb is a field element below the modulus
+const Limb_Bits = 248
+
+// Number of limbs of PxlBytes
+const Nb_Limbs = (Pxl_Bits + Limb_Bits - 1) / Limb_Bits
+
 // Returns []byte represensation of an Image
 // This function is used to define the PxlBytes field of an image in NewImage()
-// Image -> big.Int -> fr.Element -> []byte
+// Image -> big.Int -> Pxl_Bits big-endian bits -> Nb_Limbs limbs of Limb_Bits bits -> fr.Element each -> []byte
+//
+// The packed image does not fit in a field element, so it is split into limbs that each do: no two images or
+// provenances share their PxlBytes, which are hashed as Nb_Limbs field elements.
 func BigInt_to_Fr_Bytes(img Image) []byte {
+	packed := ImageToBigInt(img)
+
+	pxl_bytes := make([]byte, 0, Nb_Limbs*fr.Bytes)
 	var fe fr.Element
-	imgBigInt := ImageToBigInt(img)
-	fe.SetBigInt(imgBigInt)
+	for i := 0; i < Nb_Limbs; i++ {
+		// Limb i holds bits [i*Limb_Bits, (i+1)*Limb_Bits) counted from the most significant one
+		width := min(Limb_Bits, Pxl_Bits-i*Limb_Bits)
+		limb := new(big.Int).Rsh(packed, uint(Pxl_Bits-i*Limb_Bits-width))
+		limb.And(limb, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(width)), big.NewInt(1)))
+
+		fe.SetBigInt(limb)
+		limb_bytes := fe.Bytes()
+		pxl_bytes = append(pxl_bytes, limb_bytes[:]...)
+	}
 
-	return fe.Marshal()
+	return pxl_bytes
 }
 
 /* ------------------------------------In-Circuit & Out-of-Circuit Hash Functions-------------------------------- */
 
-// Return MiMC hash digest of the PxlBytes of an Image
+// Return MiMC hash digest of the PxlBytes and metadata of an Image, see Hash.ImageHash() for other hash functions
 func ImageHash(img Image) []byte {
-	h := out_mimc.NewMiMC()
-	h.Write(img.PxlBytes)
-	return h.Sum(nil)
+	return Hash_MiMC.ImageHash(img)
 }
 
-// Return MiMC hash digest of the PxlBytes of an Fr_Image
+// Return MiMC hash digest of the PxlBytes and metadata of an Fr_Image, see Hash.Fr_ImageHash()
 func Fr_ImageHash(api frontend.API, img Fr_Image) frontend.Variable {
-	mimc, _ := mimc.NewMiMC(api)
-	mimc.Write(img.PxlBytes)
-	digest := mimc.Sum()
-	return digest
+	return Hash_MiMC.Fr_ImageHash(api, img)
+}
+
+// [Gnark-friendly] Returns the PxlBytes of an Fr_Image recomputed from its pixels and provenance.
+// This mirrors ImageToBigInt() & BigInt_to_Fr_Bytes(): the packed bits are split into the same limbs.
+// Each RGB value is asserted to fit in 8 bits and each provenance value in 64 bits, so the packing is unambiguous.
+func Fr_PxlBytes(api frontend.API, img Fr_Image) [Nb_Limbs]frontend.Variable {
+	// Every packed bit, from the most significant one
+	bits := make([]frontend.Variable, 0, Pxl_Bits)
+	pack := func(v frontend.Variable, nb_bits int, width int) {
+		le := api.ToBinary(v, nb_bits)
+		for i := width - 1; i >= 0; i-- {
+			if i < nb_bits {
+				bits = append(bits, le[i])
+			} else {
+				bits = append(bits, frontend.Variable(0))
+			}
+		}
+	}
+
+	// Pack each pixel into 32 bits
+	for _, px := range img.Pxls {
+		pack(px.RGB[0], 8, 16)
+		pack(px.RGB[1], 8, 8)
+		pack(px.RGB[2], 8, 8)
+	}
+
+	// Append Provenance info, 64 bits for each of Tr_Name & Tr_Bound
+	for _, prov := range img.Provenance {
+		pack(prov.Tr_Name, 64, 64)
+		pack(prov.Tr_Bound, 64, 64)
+	}
+
+	var limbs [Nb_Limbs]frontend.Variable
+	for i := range limbs {
+		limb := bits[i*Limb_Bits : min((i+1)*Limb_Bits, len(bits))]
+		le := make([]frontend.Variable, len(limb))
+		for j := range limb {
+			le[len(limb)-1-j] = limb[j]
+		}
+		limbs[i] = api.FromBinary(le...)
+	}
+
+	return limbs
+}
+
+// [Gnark-friendly] Asserts that the PxlBytes of an Fr_Image are those of its pixels and provenance, see Fr_PxlBytes()
+func Fr_AssertPxlBytes(api frontend.API, img Fr_Image) {
+	for i, limb := range Fr_PxlBytes(api, img) {
+		api.AssertIsEqual(img.PxlBytes[i], limb)
+	}
 }
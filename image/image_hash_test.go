@@ -0,0 +1,97 @@
+package image
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// Returns nil if the assignment satisfies the circuit
+func solve(t *testing.T, circuit frontend.Circuit, assignment frontend.Circuit) error {
+	t.Helper()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ccs.IsSolved(witness)
+}
+
+// Returns a new image of the given flag ("white", "black" or "random")
+func newTestImage(t *testing.T, flag string) Image {
+	t.Helper()
+	img, err := NewImage(flag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// Returns an image whose packed pixels & provenance are img's plus the scalar field's modulus, which a single
+// field element cannot tell apart from img. img's last two provenance entries must be 0.
+func plusModulus(t *testing.T, img Image) Image {
+	t.Helper()
+	var modulus [32]byte
+	fr.Modulus().FillBytes(modulus[:])
+	word := func(i int) uint64 { return new(big.Int).SetBytes(modulus[8*i : 8*i+8]).Uint64() }
+
+	img.Provenance[P-2] = Provenance{Tr_Name: word(0), Tr_Bound: word(1)}
+	img.Provenance[P-1] = Provenance{Tr_Name: word(2), Tr_Bound: word(3)}
+	img.PxlBytes = BigInt_to_Fr_Bytes(img)
+	return img
+}
+
+func TestPxlBytes_Injective(t *testing.T) {
+	a := newTestImage(t, "random")
+	b := plusModulus(t, a)
+
+	diff := new(big.Int).Sub(ImageToBigInt(b), ImageToBigInt(a))
+	if diff.Cmp(fr.Modulus()) != 0 {
+		t.Fatalf("images differ by %s, expected the modulus", diff)
+	}
+	if bytes.Equal(a.PxlBytes, b.PxlBytes) {
+		t.Fatal("images that differ by the modulus share their PxlBytes")
+	}
+	if bytes.Equal(ImageHash(a), ImageHash(b)) {
+		t.Fatal("images that differ by the modulus share their hash")
+	}
+}
+
+type pxlBytesCircuit struct {
+	Img Fr_Image
+}
+
+func (circuit *pxlBytesCircuit) Define(api frontend.API) error {
+	Fr_AssertPxlBytes(api, circuit.Img)
+	return nil
+}
+
+func TestFr_PxlBytes(t *testing.T) {
+	a := newTestImage(t, "random")
+	if err := solve(t, &pxlBytesCircuit{}, &pxlBytesCircuit{Img: ImageToFr(a)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pixels & provenance of an image that a single field element cannot tell apart from a
+	b := plusModulus(t, a)
+	forged := ImageToFr(b)
+	forged.PxlBytes = ImageToFr(a).PxlBytes
+	if err := solve(t, &pxlBytesCircuit{}, &pxlBytesCircuit{Img: forged}); err == nil {
+		t.Fatal("PxlBytes were accepted for another image")
+	}
+
+	// An RGB value above 8 bits
+	forged = ImageToFr(a)
+	forged.Pxls[0].RGB[2] = 256
+	if err := solve(t, &pxlBytesCircuit{}, &pxlBytesCircuit{Img: forged}); err == nil {
+		t.Fatal("an RGB value above 8 bits was accepted")
+	}
+}
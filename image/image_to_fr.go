@@ -1,6 +1,7 @@
 package image
 
 import (
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -29,9 +30,14 @@ func ImageToFr(img Image) Fr_Image {
 		fr_image.Pxls[i] = PixelToFr(img.Pxls[i])
 	}
 
-	// Set PxlBytes & Provenance fields
-	fr_image.PxlBytes = img.PxlBytes
+	// Set PxlBytes & Provenance fields, PxlBytes is split back into its limbs
+	for i := range fr_image.PxlBytes {
+		if len(img.PxlBytes) >= (i+1)*fr.Bytes {
+			fr_image.PxlBytes[i] = img.PxlBytes[i*fr.Bytes : (i+1)*fr.Bytes]
+		}
+	}
 	fr_image.Provenance = ProvenanceToFr(img.Provenance)
+	fr_image.Metadata = img.Metadata.ToFr()
 
 	return fr_image
 }
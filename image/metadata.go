@@ -0,0 +1,139 @@
+package image
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	out_mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+/*------------------------------------------- Capture Metadata ------------------------------------------*/
+
+/*
+	Metadata is recorded by the camera and hashed with the pixels (see ImageHash()), so it is signed at capture
+	and by every editor. Edits carry it over unchanged, except for the GPS location, which the "redact_gps"
+	transformation strips. GPS_Recorded stays set once the location is stripped, so a verifier still knows that
+	there was one.
+*/
+
+// Capture metadata of an image
+type Metadata struct {
+	Device_Serial string `json:"device_serial,omitempty"`
+	GPS           *GPS   `json:"gps,omitempty"`          // Location of the capture, nil if there was no fix or it was redacted
+	GPS_Recorded  bool   `json:"gps_recorded,omitempty"` // The camera recorded a location, even if it was redacted since
+	Exposure      uint64 `json:"exposure,omitempty"`     // Exposure time, in microseconds
+	Focal_Length  uint64 `json:"focal_length,omitempty"` // In micrometres
+}
+
+// A GPS location, in microdegrees
+type GPS struct {
+	Latitude  int64 `json:"latitude"`
+	Longitude int64 `json:"longitude"`
+}
+
+// Returns true if the image's GPS location was recorded then redacted
+func (md Metadata) GPS_Redacted() bool {
+	return md.GPS_Recorded && md.GPS == nil
+}
+
+// Returns an error if the metadata is not well formed
+func (md Metadata) Validate() error {
+	if md.GPS == nil {
+		return nil
+	}
+	if !md.GPS_Recorded {
+		return fmt.Errorf("image: GPS location is set but not recorded")
+	}
+	if md.GPS.Latitude < -90_000_000 || md.GPS.Latitude > 90_000_000 || md.GPS.Longitude < -180_000_000 || md.GPS.Longitude > 180_000_000 {
+		return fmt.Errorf("image: GPS location (%d, %d) is out of range", md.GPS.Latitude, md.GPS.Longitude)
+	}
+	return nil
+}
+
+// Returns the field element standing for a device serial: SHA-256 of the serial reduced into the scalar field,
+// 0 for no serial
+func DeviceId(serial string) []byte {
+	var fe fr.Element
+	if serial != "" {
+		sum := sha256.Sum256([]byte(serial))
+		fe.SetBytes(sum[:])
+	}
+	return fe.Marshal()
+}
+
+// Returns the MiMC digest of the metadata, mirroring Fr_MetadataDigest()
+func MetadataDigest(md Metadata) []byte {
+	h := out_mimc.NewMiMC()
+
+	var fe fr.Element
+	for _, v := range md.elements() {
+		fe.SetBigInt(v)
+		h.Write(fe.Marshal())
+	}
+	return h.Sum(nil)
+}
+
+// Returns the metadata as field elements, in the order they are hashed
+func (md Metadata) elements() [7]*big.Int {
+	var device fr.Element
+	device.SetBytes(DeviceId(md.Device_Serial))
+
+	var lat, lon fr.Element
+	present := uint64(0)
+	if md.GPS != nil {
+		present = 1
+		lat.SetInt64(md.GPS.Latitude)
+		lon.SetInt64(md.GPS.Longitude)
+	}
+	recorded := uint64(0)
+	if md.GPS_Recorded {
+		recorded = 1
+	}
+
+	return [7]*big.Int{
+		device.BigInt(new(big.Int)),
+		new(big.Int).SetUint64(recorded),
+		new(big.Int).SetUint64(present),
+		lat.BigInt(new(big.Int)),
+		lon.BigInt(new(big.Int)),
+		new(big.Int).SetUint64(md.Exposure),
+		new(big.Int).SetUint64(md.Focal_Length),
+	}
+}
+
+/*------------------------------------------ Gnark-Friendly Metadata --------------------------------------*/
+
+// [Gnark-friendly] Metadata, as field elements
+type Fr_Metadata struct {
+	Device_Id    frontend.Variable // DeviceId() of the serial
+	GPS_Recorded frontend.Variable // 1 or 0
+	GPS_Present  frontend.Variable // 1 or 0, 0 once redacted
+	Latitude     frontend.Variable // 0 without a location, negative values wrap around the field
+	Longitude    frontend.Variable
+	Exposure     frontend.Variable
+	Focal_Length frontend.Variable
+}
+
+func (md Metadata) ToFr() Fr_Metadata {
+	e := md.elements()
+	return Fr_Metadata{
+		Device_Id:    e[0],
+		GPS_Recorded: e[1],
+		GPS_Present:  e[2],
+		Latitude:     e[3],
+		Longitude:    e[4],
+		Exposure:     e[5],
+		Focal_Length: e[6],
+	}
+}
+
+// [In-Circuit] Returns the MiMC digest of the metadata, mirroring MetadataDigest()
+func Fr_MetadataDigest(api frontend.API, md Fr_Metadata) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+	h.Write(md.Device_Id, md.GPS_Recorded, md.GPS_Present, md.Latitude, md.Longitude, md.Exposure, md.Focal_Length)
+	return h.Sum()
+}
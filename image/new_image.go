@@ -68,19 +68,14 @@ func NewImage(flag string) (Image, error) {
 		}
 	}
 
-	// Set PxlBytes & Provenance fields
+	// Set PxlBytes field. Provenance is left empty, it is set by the camera with SetProvenance().
 	newImage.PxlBytes = BigInt_to_Fr_Bytes(newImage)
-	newImage.Provenance = [P]Provenance{
-		{
-			Tr_Name:  0, // "identity" == 0
-			Tr_Bound: 1,
-		},
-		// TODO add other permissible provenances
-		// Provenance{
-		//	    Tr_Name: "increase total contrast"
-		//		Tr_Bound: 10
-		// }
-	}
 
 	return newImage, nil
 }
+
+// Set the image's Provenance and recompute its PxlBytes, since provenance is hashed alongside pixels
+func (img *Image) SetProvenance(provenance [P]Provenance) {
+	img.Provenance = provenance
+	img.PxlBytes = BigInt_to_Fr_Bytes(*img)
+}
@@ -0,0 +1,51 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+/*----------------------------------------------- PNG Conversion -------------------------------------*/
+
+// Decode an N*N PNG into an Image. Alpha is ignored and provenance is left empty, as in NewImage().
+func FromPNG(r io.Reader) (Image, error) {
+	decoded, err := png.Decode(r)
+	if err != nil {
+		return Image{}, err
+	}
+
+	bounds := decoded.Bounds()
+	if bounds.Dx() != int(N) || bounds.Dy() != int(N) {
+		return Image{}, fmt.Errorf("image: PNG is %dx%d, expected %dx%d", bounds.Dx(), bounds.Dy(), N, N)
+	}
+
+	img := Image{Pxls: [N2]Pixel{}}
+	for row := 0; row < int(N); row++ {
+		for col := 0; col < int(N); col++ {
+			c := color.NRGBAModel.Convert(decoded.At(bounds.Min.X+col, bounds.Min.Y+row)).(color.NRGBA)
+
+			// Translate the 2D location (x,y) into a 1D index.
+			idx := row*int(N) + col
+			img.Pxls[idx] = Pixel{
+				RGB: [3]uint8{c.R, c.G, c.B},
+				Loc: PixelLocation{X: uint64(col), Y: uint64(row)},
+			}
+		}
+	}
+
+	img.PxlBytes = BigInt_to_Fr_Bytes(img)
+
+	return img, nil
+}
+
+// Encode the image's pixels as an opaque N*N PNG
+func (img Image) PNG(w io.Writer) error {
+	out := goimage.NewNRGBA(goimage.Rect(0, 0, int(N), int(N)))
+	for _, pxl := range img.Pxls {
+		out.SetNRGBA(int(pxl.Loc.X), int(pxl.Loc.Y), color.NRGBA{R: pxl.RGB[0], G: pxl.RGB[1], B: pxl.RGB[2], A: 255})
+	}
+	return png.Encode(w, out)
+}
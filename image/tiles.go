@@ -0,0 +1,287 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	out_mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+/*------------------------------------------- Tile Commitments ------------------------------------------*/
+
+/*
+	ImageHash() and ImageCommitment() hash the image as one blob, so any claim about part of an image needs every
+	pixel in the circuit. A tile commitment instead splits the image into Tile×Tile tiles and commits to them with
+	a Merkle tree of fixed depth, so a circuit can open or replace a single tile with its Merkle path:
+
+		leaf  = MiMC(Tile_Leaf_Tag, packed pixels of the tile, row by row), pixels beyond the image's edge are 0
+		        0 for the empty leaves past the last tile
+		node  = MiMC(Tile_Node_Tag, left, right)
+		root  = the node at depth Tile_Depth
+
+	The tags separate leaves from nodes, so a node cannot be opened as a leaf nor a leaf as a node.
+
+	Tiles are numbered row by row. Two images whose roots are both reached from the same Merkle path and a tile's
+	leaves only differ in that tile: every other tile is fixed by the shared siblings (see Fr_UpdateTile()).
+
+	NOTE: the tile root is a standalone commitment. Neither the camera's signature nor the PhotoGnark circuits
+	bind it to an image hash, so a tile proof only shows that two roots differ in one tile, not that either root
+	belongs to a signed photograph.
+*/
+
+// Side of a tile, in pixels
+const Tile uint64 = 2
+
+// Number of tiles per row & column, the last ones may overhang the image
+const Tiles_N uint64 = (N + Tile - 1) / Tile
+
+// Number of tiles of an image
+const Tiles uint64 = Tiles_N * Tiles_N
+
+// Number of pixels of a tile
+const Tile2 uint64 = Tile * Tile
+
+// Depth of the tile tree, so an image has at most 2^Tile_Depth tiles
+const Tile_Depth = 4
+
+// Domain tags of the leaves & nodes of the tile tree, hashed first
+const (
+	Tile_Leaf_Tag = 0
+	Tile_Node_Tag = 1
+)
+
+// Fails to compile if the tiles do not fit in the tree
+var _ [1<<Tile_Depth - Tiles]struct{}
+
+// Merkle path of a tile, from its leaf up to the root
+type Tile_Path struct {
+	Index    uint64
+	Siblings [Tile_Depth][]byte
+}
+
+// Returns the pixels of tile t row by row, with zero pixels beyond the image's edge
+func (img Image) Tile(t uint64) [Tile2]Pixel {
+	var tile [Tile2]Pixel
+	x0, y0 := (t%Tiles_N)*Tile, (t/Tiles_N)*Tile
+	for dy := uint64(0); dy < Tile; dy++ {
+		for dx := uint64(0); dx < Tile; dx++ {
+			if x0+dx < N && y0+dy < N {
+				tile[dy*Tile+dx] = img.Pxls[To_1D_Index(x0+dx, y0+dy)]
+			}
+		}
+	}
+	return tile
+}
+
+// Returns the Merkle leaf of a tile's pixels
+func TileLeaf(tile [Tile2]Pixel) []byte {
+	h := out_mimc.NewMiMC()
+
+	var fe fr.Element
+	fe.SetUint64(Tile_Leaf_Tag)
+	h.Write(fe.Marshal())
+	for _, px := range tile {
+		fe.SetUint64(uint64(px.RGB[0])<<16 | uint64(px.RGB[1])<<8 | uint64(px.RGB[2]))
+		h.Write(fe.Marshal())
+	}
+	return h.Sum(nil)
+}
+
+// Returns the root of the image's tile tree, which commits to its pixels only
+func TileRoot(img Image) []byte {
+	return tileTree(img)[Tile_Depth][0]
+}
+
+// Returns the Merkle path of tile t
+func TilePath(img Image, t uint64) (Tile_Path, error) {
+	if t >= Tiles {
+		return Tile_Path{}, fmt.Errorf("image: tile %d of %d", t, Tiles)
+	}
+
+	levels := tileTree(img)
+	path := Tile_Path{Index: t}
+	index := t
+	for level := 0; level < Tile_Depth; level++ {
+		path.Siblings[level] = levels[level][index^1]
+		index >>= 1
+	}
+	return path, nil
+}
+
+// Returns the tiles whose pixels differ between both images
+func ChangedTiles(a Image, b Image) []uint64 {
+	changed := []uint64{}
+	for t := uint64(0); t < Tiles; t++ {
+		if !bytes.Equal(TileLeaf(a.Tile(t)), TileLeaf(b.Tile(t))) {
+			changed = append(changed, t)
+		}
+	}
+	return changed
+}
+
+// Return the MiMC commitment to the tile root, provenance and metadata of an Image, like ImageCommitment()
+func TileCommitment(img Image) []byte {
+	h := out_mimc.NewMiMC()
+	h.Write(TileRoot(img))
+
+	var fe fr.Element
+	for _, prov := range img.Provenance {
+		fe.SetUint64(prov.Tr_Name)
+		h.Write(fe.Marshal())
+		fe.SetUint64(prov.Tr_Bound)
+		h.Write(fe.Marshal())
+	}
+	h.Write(MetadataDigest(img.Metadata))
+
+	return h.Sum(nil)
+}
+
+// Returns every level of the image's tile tree, from the leaves (level 0) to the root (level Tile_Depth)
+func tileTree(img Image) [][][]byte {
+	levels := make([][][]byte, Tile_Depth+1)
+	levels[0] = make([][]byte, 1<<Tile_Depth)
+	for t := range levels[0] {
+		levels[0][t] = make([]byte, fr.Bytes) // Empty leaves are 0
+		if uint64(t) < Tiles {
+			levels[0][t] = TileLeaf(img.Tile(uint64(t)))
+		}
+	}
+
+	var tag fr.Element
+	tag.SetUint64(Tile_Node_Tag)
+	for level := 1; level <= Tile_Depth; level++ {
+		below := levels[level-1]
+		levels[level] = make([][]byte, len(below)/2)
+		for i := range levels[level] {
+			h := out_mimc.NewMiMC()
+			h.Write(tag.Marshal())
+			h.Write(below[2*i])
+			h.Write(below[2*i+1])
+			levels[level][i] = h.Sum(nil)
+		}
+	}
+
+	return levels
+}
+
+/*------------------------------------------ Gnark-Friendly Tile Commitments --------------------------------------*/
+
+// [Gnark-friendly] Tile_Path
+type Fr_Tile_Path struct {
+	Index    frontend.Variable
+	Siblings [Tile_Depth]frontend.Variable
+}
+
+func (path Tile_Path) ToFr() Fr_Tile_Path {
+	fr_path := Fr_Tile_Path{Index: path.Index}
+	for i, sibling := range path.Siblings {
+		fr_path.Siblings[i] = sibling
+	}
+	return fr_path
+}
+
+// Returns the Fr_Pixel version of a tile's pixels
+func TileToFr(tile [Tile2]Pixel) [Tile2]Fr_Pixel {
+	var fr_tile [Tile2]Fr_Pixel
+	for i, px := range tile {
+		fr_tile[i] = PixelToFr(px)
+	}
+	return fr_tile
+}
+
+// [In-Circuit] Returns the pixels of tile t, mirroring Image.Tile()
+func (img Fr_Image) Tile(t uint64) [Tile2]Fr_Pixel {
+	tile := TileToFr([Tile2]Pixel{})
+	x0, y0 := (t%Tiles_N)*Tile, (t/Tiles_N)*Tile
+	for dy := uint64(0); dy < Tile; dy++ {
+		for dx := uint64(0); dx < Tile; dx++ {
+			if x0+dx < N && y0+dy < N {
+				tile[dy*Tile+dx] = img.Pxls[To_1D_Index(x0+dx, y0+dy)]
+			}
+		}
+	}
+	return tile
+}
+
+// [In-Circuit] Returns the Merkle leaf of a tile's pixels, mirroring TileLeaf().
+// Every channel is range checked to 8 bits, otherwise R<<16|G<<8|B would not be a unique packing of the pixel.
+func Fr_TileLeaf(api frontend.API, tile [Tile2]Fr_Pixel) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+	h.Write(Tile_Leaf_Tag)
+	for _, px := range tile {
+		for _, channel := range px.RGB {
+			api.ToBinary(channel, 8)
+		}
+		h.Write(api.Add(api.Mul(px.RGB[0], 1<<16), api.Mul(px.RGB[1], 1<<8), px.RGB[2]))
+	}
+	return h.Sum()
+}
+
+// [In-Circuit] Returns the root of the tile tree in which leaf is at path.
+// The index is asserted to be one of the image's tiles, so every path has a single index.
+func Fr_TileRoot(api frontend.API, leaf frontend.Variable, path Fr_Tile_Path) frontend.Variable {
+	api.AssertIsLessOrEqual(path.Index, Tiles-1)
+
+	h, _ := mimc.NewMiMC(api)
+	node := leaf
+
+	bits := api.ToBinary(path.Index, Tile_Depth)
+	for level, sibling := range path.Siblings {
+		// bit == 1: node is the right child
+		left := api.Select(bits[level], sibling, node)
+		right := api.Select(bits[level], node, sibling)
+
+		h.Reset()
+		h.Write(Tile_Node_Tag, left, right)
+		node = h.Sum()
+	}
+
+	return node
+}
+
+// [In-Circuit] Asserts that leaf_in is the tile at path in the tree of root_in, and returns the root of the same
+// tree with that tile replaced by leaf_out. Every other tile is unchanged, by equality of the siblings' hashes.
+func Fr_UpdateTile(api frontend.API, root_in frontend.Variable, leaf_in frontend.Variable, leaf_out frontend.Variable, path Fr_Tile_Path) frontend.Variable {
+	api.AssertIsEqual(Fr_TileRoot(api, leaf_in, path), root_in)
+	return Fr_TileRoot(api, leaf_out, path)
+}
+
+// [In-Circuit] Returns the root of the image's tile tree from every tile, mirroring TileRoot()
+func Fr_TileTreeRoot(api frontend.API, img Fr_Image) frontend.Variable {
+	var level [1 << Tile_Depth]frontend.Variable
+	for t := range level {
+		level[t] = 0 // Empty leaves are 0
+		if uint64(t) < Tiles {
+			level[t] = Fr_TileLeaf(api, img.Tile(uint64(t)))
+		}
+	}
+
+	h, _ := mimc.NewMiMC(api)
+	nodes := level[:]
+	for len(nodes) > 1 {
+		for i := range nodes[:len(nodes)/2] {
+			h.Reset()
+			h.Write(Tile_Node_Tag, nodes[2*i], nodes[2*i+1])
+			nodes[i] = h.Sum()
+		}
+		nodes = nodes[:len(nodes)/2]
+	}
+	return nodes[0]
+}
+
+// [In-Circuit] Return the MiMC commitment to the tile root, provenance and metadata of an Fr_Image, mirroring
+// TileCommitment(). The pixels are range checked by Fr_TileLeaf().
+func Fr_TileCommitment(api frontend.API, img Fr_Image) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+	h.Write(Fr_TileTreeRoot(api, img))
+
+	for _, prov := range img.Provenance {
+		h.Write(prov.Tr_Name, prov.Tr_Bound)
+	}
+	h.Write(Fr_MetadataDigest(api, img.Metadata))
+
+	return h.Sum()
+}
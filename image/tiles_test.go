@@ -0,0 +1,147 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+type tileUpdateCircuit struct {
+	Root_in  frontend.Variable `gnark:",public"`
+	Root_out frontend.Variable `gnark:",public"`
+	Tile_in  [Tile2]Fr_Pixel
+	Tile_out [Tile2]Fr_Pixel
+	Path     Fr_Tile_Path
+}
+
+func (circuit *tileUpdateCircuit) Define(api frontend.API) error {
+	leaf_in := Fr_TileLeaf(api, circuit.Tile_in)
+	leaf_out := Fr_TileLeaf(api, circuit.Tile_out)
+	api.AssertIsEqual(Fr_UpdateTile(api, circuit.Root_in, leaf_in, leaf_out, circuit.Path), circuit.Root_out)
+	return nil
+}
+
+type tileCommitmentCircuit struct {
+	Img        Fr_Image
+	Commitment frontend.Variable `gnark:",public"`
+}
+
+func (circuit *tileCommitmentCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(Fr_TileCommitment(api, circuit.Img), circuit.Commitment)
+	return nil
+}
+
+// Returns an image and a copy of it with a single pixel changed, in its last tile
+func testTileEdit(t *testing.T) (Image, Image, uint64) {
+	t.Helper()
+	img_in := newTestImage(t, "random")
+	img_out := img_in
+	img_out.Pxls[To_1D_Index(N-1, N-1)].RGB[0] ^= 0xff
+
+	changed := ChangedTiles(img_in, img_out)
+	if len(changed) != 1 {
+		t.Fatalf("%d tiles changed, expected 1", len(changed))
+	}
+	return img_in, img_out, changed[0]
+}
+
+// Returns the assignment of an update of tile t from img_in to img_out
+func tileUpdate(t *testing.T, img_in Image, img_out Image, tile uint64) tileUpdateCircuit {
+	t.Helper()
+	path, err := TilePath(img_in, tile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tileUpdateCircuit{
+		Root_in:  TileRoot(img_in),
+		Root_out: TileRoot(img_out),
+		Tile_in:  TileToFr(img_in.Tile(tile)),
+		Tile_out: TileToFr(img_out.Tile(tile)),
+		Path:     path.ToFr(),
+	}
+}
+
+func TestFr_UpdateTile(t *testing.T) {
+	img_in, img_out, tile := testTileEdit(t)
+
+	assignment := tileUpdate(t, img_in, img_out, tile)
+	if err := solve(t, &tileUpdateCircuit{}, &assignment); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another tile changed as well
+	other := img_out
+	other.Pxls[0].RGB[0] ^= 0xff
+	assignment = tileUpdate(t, img_in, img_out, tile)
+	assignment.Root_out = TileRoot(other)
+	if err := solve(t, &tileUpdateCircuit{}, &assignment); err == nil {
+		t.Fatal("an edit of two tiles was accepted as an edit of one")
+	}
+
+	// The tile before the edit is not the one at the path
+	assignment = tileUpdate(t, img_in, img_out, tile)
+	assignment.Tile_in = assignment.Tile_out
+	if err := solve(t, &tileUpdateCircuit{}, &assignment); err == nil {
+		t.Fatal("a tile that is not in the image was opened")
+	}
+
+	// The path is of another tile
+	assignment = tileUpdate(t, img_in, img_out, tile)
+	assignment.Path.Index = 0
+	if err := solve(t, &tileUpdateCircuit{}, &assignment); err == nil {
+		t.Fatal("a tile was opened at another index")
+	}
+
+	// A tile whose channels overflow into their neighbours packs like the edited one
+	assignment = tileUpdate(t, img_in, img_out, tile)
+	px := img_out.Tile(tile)[Tile2-1]
+	assignment.Tile_out[Tile2-1].RGB[0] = int(px.RGB[0]) - 1
+	assignment.Tile_out[Tile2-1].RGB[1] = int(px.RGB[1]) + 256
+	if err := solve(t, &tileUpdateCircuit{}, &assignment); err == nil {
+		t.Fatal("a tile with a channel above 255 was accepted")
+	}
+
+	// An index past the last tile
+	assignment = tileUpdate(t, img_in, img_out, tile)
+	assignment.Path.Index = Tiles
+	if err := solve(t, &tileUpdateCircuit{}, &assignment); err == nil {
+		t.Fatal("a tile was opened past the last one")
+	}
+}
+
+func TestTileLeaf_DomainSeparated(t *testing.T) {
+	img := newTestImage(t, "random")
+	levels := tileTree(img)
+
+	// An all-black tile and an empty subtree are distinct
+	var tile [Tile2]Pixel
+	if string(TileLeaf(tile)) == string(levels[1][len(levels[1])-1]) {
+		t.Fatal("a black tile hashes to an empty node")
+	}
+	empty := make([]byte, len(levels[0][0]))
+	for level := 1; level <= Tile_Depth; level++ {
+		for _, node := range levels[level] {
+			for t2 := uint64(0); t2 < Tiles; t2++ {
+				if string(node) == string(levels[0][t2]) {
+					t.Fatalf("node at level %d equals leaf %d", level, t2)
+				}
+			}
+			if string(node) == string(empty) {
+				t.Fatalf("node at level %d is an empty leaf", level)
+			}
+		}
+	}
+}
+
+func TestFr_TileCommitment(t *testing.T) {
+	img := newTestImage(t, "random")
+	if err := solve(t, &tileCommitmentCircuit{}, &tileCommitmentCircuit{Img: ImageToFr(img), Commitment: TileCommitment(img)}); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := img
+	edited.Pxls[0].RGB[1] ^= 0x01
+	if err := solve(t, &tileCommitmentCircuit{}, &tileCommitmentCircuit{Img: ImageToFr(edited), Commitment: TileCommitment(img)}); err == nil {
+		t.Fatal("the tile commitment of an image was accepted for another")
+	}
+}
@@ -7,13 +7,16 @@ import (
 )
 
 /*-------------------------------------------- Z Construction -------------------------------------------*/
-// Z = (Image, Public Key, original hash & signature)
+// Z = (Image, Public Key, original hash & signature, capture)
 type Z struct {
 	Img                Image
 	Original_PublicKey signature.PublicKey
 	// Original signature and hash
 	Original_Signature []byte
 	Original_Hash      []byte
+	// When the original image was captured, hashed into Original_Hash
+	Capture   Capture
+	Timestamp *Timestamp_Token // Set if a timestamp authority set the capture time
 }
 
 func (z Z) ToFr() Fr_Z {
@@ -21,14 +24,20 @@ func (z Z) ToFr() Fr_Z {
 	var eddsa_digSig eddsa.Signature
 	var eddsa_PK eddsa.PublicKey
 
-	eddsa_digSig.Assign(1, z.Original_Signature)
-	eddsa_PK.Assign(1, z.Original_PublicKey.Bytes())
+	// A hidden camera's key and signature may be left out of Z (see photoproof.Mode_Hidden_Camera)
+	if z.Original_Signature != nil {
+		eddsa_digSig.Assign(1, z.Original_Signature)
+	}
+	if z.Original_PublicKey != nil {
+		eddsa_PK.Assign(1, z.Original_PublicKey.Bytes())
+	}
 
 	return Fr_Z{
 		Img:                ImageToFr(z.Img),
 		Original_PublicKey: eddsa_PK,
 		Original_Signature: eddsa_digSig,
 		Original_Hash:      frontend.Variable(z.Original_Hash),
+		Capture:            z.Capture.ToFr(),
 	}
 }
 
@@ -39,4 +48,5 @@ type Fr_Z struct {
 	// Original signature and hash
 	Original_Signature eddsa.Signature
 	Original_Hash      frontend.Variable
+	Capture            Fr_Capture
 }
@@ -0,0 +1,281 @@
+// Package keystore keeps the secret keys of Admins and editors on disk, encrypted with a passphrase, so a camera
+// or an editor keeps the same identity, and the same PublicKey_out, across sessions:
+//
+//	ks, err := keystore.Open("keys")
+//	err = ks.Save("alice", user, passphrase)
+//	user, err := ks.Load("alice", passphrase)
+//
+// Every key is a JSON file named after the key. Its secret key is encrypted with AES-256-GCM under a key derived
+// from the passphrase with Argon2id; its public key and fingerprint are stored in the clear, so keys can be listed
+// without the passphrase.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+var (
+	ErrPassphrase  = errors.New("keystore: wrong passphrase or corrupted key")
+	ErrExists      = errors.New("keystore: key already exists")
+	ErrInvalidName = errors.New("keystore: invalid key name")
+)
+
+/*-----------------------------------------------------Key File--------------------------------------------------*/
+
+const (
+	Version    = 1
+	Key_Suffix = ".key.json"
+)
+
+// Argon2id cost parameters
+type KDF_Params struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+}
+
+// The parameters RFC 9106 recommends for memory-constrained environments
+var Default_KDF = KDF_Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// The largest parameters accepted, so that a key file cannot make Decrypt() spend unbounded time or memory
+var Max_KDF = KDF_Params{Time: 4 * 3, Memory: 4 * 64 * 1024, Threads: 4 * 4}
+
+// An encrypted key, as stored on disk
+type Key_File struct {
+	Version     int        `json:"version"`
+	Name        string     `json:"name"`
+	PublicKey   []byte     `json:"public_key"`
+	Fingerprint string     `json:"fingerprint"`
+	Created     time.Time  `json:"created"`
+	KDF         string     `json:"kdf"` // "argon2id"
+	KDF_Params  KDF_Params `json:"kdf_params"`
+	Salt        []byte     `json:"salt"`
+	Cipher      string     `json:"cipher"` // "aes-256-gcm"
+	Nonce       []byte     `json:"nonce"`
+	Ciphertext  []byte     `json:"ciphertext"` // Secret key, authenticated together with the public key
+}
+
+// Returns the user's key encrypted with passphrase
+func Encrypt(name string, user photoproof.User, passphrase []byte, params KDF_Params) (Key_File, error) {
+	secret, err := user.SecretKeyBytes()
+	if err != nil {
+		return Key_File{}, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Key_File{}, err
+	}
+	aead, err := newAEAD(passphrase, salt, params)
+	if err != nil {
+		return Key_File{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Key_File{}, err
+	}
+
+	public := user.PublicKey.Bytes()
+	return Key_File{
+		Version:     Version,
+		Name:        name,
+		PublicKey:   public,
+		Fingerprint: user.Fingerprint(),
+		Created:     time.Now().UTC(),
+		KDF:         "argon2id",
+		KDF_Params:  params,
+		Salt:        salt,
+		Cipher:      "aes-256-gcm",
+		Nonce:       nonce,
+		Ciphertext:  aead.Seal(nil, nonce, secret, public),
+	}, nil
+}
+
+// Returns the user whose key was encrypted with passphrase.
+// Returns ErrPassphrase if the passphrase is wrong or the file was altered.
+func (file Key_File) Decrypt(passphrase []byte) (photoproof.User, error) {
+	if file.Version != Version || file.KDF != "argon2id" || file.Cipher != "aes-256-gcm" {
+		return photoproof.User{}, fmt.Errorf("%w: unsupported key file (version %d, %s, %s)", photoproof.ErrDecode, file.Version, file.KDF, file.Cipher)
+	}
+
+	aead, err := newAEAD(passphrase, file.Salt, file.KDF_Params)
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return photoproof.User{}, ErrPassphrase
+	}
+	secret, err := aead.Open(nil, file.Nonce, file.Ciphertext, file.PublicKey)
+	if err != nil {
+		return photoproof.User{}, ErrPassphrase
+	}
+
+	user, err := photoproof.NewUserFromBytes(secret)
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	if user.Fingerprint() != file.Fingerprint {
+		return photoproof.User{}, ErrPassphrase
+	}
+
+	return user, nil
+}
+
+func newAEAD(passphrase []byte, salt []byte, params KDF_Params) (cipher.AEAD, error) {
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, fmt.Errorf("%w: invalid argon2id parameters", photoproof.ErrDecode)
+	}
+	if params.Time > Max_KDF.Time || params.Memory > Max_KDF.Memory || params.Threads > Max_KDF.Threads {
+		return nil, fmt.Errorf("%w: argon2id parameters %+v exceed %+v", photoproof.ErrDecode, params, Max_KDF)
+	}
+
+	block, err := aes.NewCipher(argon2.IDKey(passphrase, salt, params.Time, params.Memory, params.Threads, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/*-----------------------------------------------------Keystore--------------------------------------------------*/
+
+// A directory of encrypted keys
+type Keystore struct {
+	Dir string
+	KDF KDF_Params // Used for new keys, Default_KDF if zero
+}
+
+// A key of the keystore, as listed without its passphrase
+type Entry struct {
+	Name        string    `json:"name"`
+	Fingerprint string    `json:"fingerprint"`
+	PublicKey   []byte    `json:"public_key"`
+	Created     time.Time `json:"created"`
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Returns the keystore in dir, creating the directory if needed
+func Open(dir string) (Keystore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Keystore{}, err
+	}
+	return Keystore{Dir: dir, KDF: Default_KDF}, nil
+}
+
+// Encrypt the user's key with passphrase and store it under name. Returns ErrExists if name is taken.
+func (ks Keystore) Save(name string, user photoproof.User, passphrase []byte) error {
+	path, err := ks.path(name)
+	if err != nil {
+		return err
+	}
+
+	params := ks.KDF
+	if params == (KDF_Params{}) {
+		params = Default_KDF
+	}
+	file, err := Encrypt(name, user, passphrase, params)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// O_EXCL so that two processes saving the same name cannot overwrite each other's key
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%w: %q", ErrExists, name)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// Returns the user stored under name, decrypted with passphrase.
+// Returns an error wrapping os.ErrNotExist if there is no such key.
+func (ks Keystore) Load(name string, passphrase []byte) (photoproof.User, error) {
+	file, err := ks.read(name)
+	if err != nil {
+		return photoproof.User{}, err
+	}
+	return file.Decrypt(passphrase)
+}
+
+// Returns true if a key is stored under name
+func (ks Keystore) Has(name string) bool {
+	path, err := ks.path(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Returns the keys of the keystore, sorted by name
+func (ks Keystore) List() ([]Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(ks.Dir, "*"+Key_Suffix))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, path := range paths {
+		file, err := ks.read(strings.TrimSuffix(filepath.Base(path), Key_Suffix))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Name: file.Name, Fingerprint: file.Fingerprint, PublicKey: file.PublicKey, Created: file.Created})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (ks Keystore) read(name string) (Key_File, error) {
+	path, err := ks.path(name)
+	if err != nil {
+		return Key_File{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key_File{}, err
+	}
+
+	var file Key_File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Key_File{}, fmt.Errorf("%s: %w", path, photoproof.Wrap(photoproof.ErrDecode, err))
+	}
+	if file.Name != name {
+		return Key_File{}, fmt.Errorf("%w: %s holds key %q", photoproof.ErrDecode, path, file.Name)
+	}
+	return file, nil
+}
+
+func (ks Keystore) path(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return filepath.Join(ks.Dir, name+Key_Suffix), nil
+}
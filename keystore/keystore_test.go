@@ -0,0 +1,91 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Cheap parameters, so that the tests do not spend Default_KDF's memory on every key
+var test_KDF = KDF_Params{Time: 1, Memory: 1024, Threads: 1}
+
+func testKeystore(t *testing.T) (Keystore, photoproof.User) {
+	t.Helper()
+	ks, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.KDF = test_KDF
+
+	user, err := photoproof.NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Save("editor", user, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	return ks, user
+}
+
+func TestKeystore_Load(t *testing.T) {
+	ks, user := testKeystore(t)
+
+	loaded, err := ks.Load("editor", []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Fingerprint() != user.Fingerprint() {
+		t.Fatal("loaded another key")
+	}
+
+	if _, err := ks.Load("editor", []byte("wrong")); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("wrong passphrase: got %v, expected %v", err, ErrPassphrase)
+	}
+	if err := ks.Save("editor", user, []byte("passphrase")); !errors.Is(err, ErrExists) {
+		t.Fatalf("saved twice: got %v, expected %v", err, ErrExists)
+	}
+}
+
+func TestKeystore_KDFBounds(t *testing.T) {
+	ks, _ := testKeystore(t)
+	path, err := ks.path("editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := ks.read("editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range []KDF_Params{
+		{Time: Max_KDF.Time + 1, Memory: test_KDF.Memory, Threads: test_KDF.Threads},
+		{Time: test_KDF.Time, Memory: Max_KDF.Memory + 1, Threads: test_KDF.Threads},
+		{Time: test_KDF.Time, Memory: test_KDF.Memory, Threads: Max_KDF.Threads + 1},
+		{Time: 0, Memory: test_KDF.Memory, Threads: test_KDF.Threads},
+	} {
+		altered := file
+		altered.KDF_Params = params
+		data, err := json.Marshal(altered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ks.Load("editor", []byte("passphrase")); !errors.Is(err, photoproof.ErrDecode) {
+			t.Errorf("argon2id parameters %+v: got %v, expected %v", params, err, photoproof.ErrDecode)
+		}
+	}
+
+	user, err := photoproof.NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Encrypt("editor", user, []byte("passphrase"), KDF_Params{Time: 1, Memory: Max_KDF.Memory + 1, Threads: 1}); !errors.Is(err, photoproof.ErrDecode) {
+		t.Errorf("encrypted with parameters above Max_KDF: %v", err)
+	}
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"log/slog"
+	"os"
+
 	"github.com/drakstik/PhotoGnark_ACDF/examples"
 	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
 )
 
 func main() {
+	photoproof.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
 	photo, _ := examples.TakePhoto_Example()
-	examples.EditPhoto_Example(photo, photoproof.Identity_Tr{}, photoproof.Identity_Tr_Params{})
+	edited, _ := examples.EditPhoto_Example(photo, photoproof.Identity_Tr{}, photoproof.Identity_Tr_Params{})
+	examples.VerifyPhoto_Example(edited, edited.VerifyingKeys)
 }
@@ -0,0 +1,198 @@
+package photoproof
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+/*-------------------------------------------------Batch Proving-------------------------------------------------*/
+
+// A proving job: apply tr with params to Photo and prove it.
+//
+// If Photo has no PCD proof yet (Case 1), it is an original photograph (see camera.NewPhotograph())
+// and its originality is proven instead, which requires the BatchProver's User to be the camera's Admin.
+type Job struct {
+	Id     string // Chosen by the caller to match results to jobs
+	Photo  Photograph
+	Tr     Transformation
+	Params Parameters
+}
+
+// The outcome of a Job. Photo carries its new PCD proof when Err is nil.
+type Result struct {
+	Id    string
+	Photo Photograph
+	Err   error
+}
+
+// Proves many photographs concurrently on behalf of a single User.
+//
+// A User only holds its keys and never mutates them, so one User is safely shared by every worker.
+// Each compiled circuit is shared as well, so it is compiled at most once per mode & policy per call to Prove().
+type BatchProver struct {
+	User    User
+	Workers int // Maximum number of proofs generated at once. Defaults to runtime.NumCPU()
+}
+
+// Prove every job received on jobs with a bounded pool of workers, streaming one Result per job.
+//
+// Workers stop taking new jobs once ctx is cancelled or jobs is closed; a job that was taken or still being
+// proven at cancellation reports ctx.Err(). Gnark cannot be interrupted, so a worker waits for its abandoned
+// computation to finish before taking another job or returning: at most Workers proofs are ever generated at once.
+// ctx's Observer receives the progress of every job. The returned channel is closed once every worker has returned.
+// Results are only dropped when ctx is cancelled and nobody is reading the returned channel.
+func (bp BatchProver) Prove(ctx context.Context, jobs <-chan Job) <-chan Result {
+	workers := bp.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make(chan Result)
+	predicates := compiled{predicates: map[compiledKey]constraint.ConstraintSystem{}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Every computation of the worker's jobs, including those abandoned at cancellation (see Step())
+			var pending sync.WaitGroup
+			defer pending.Wait()
+			ctx := withPending(ctx, &pending)
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job, ok := <-jobs:
+					if !ok {
+						return
+					}
+
+					result := Result{Id: job.Id}
+					if err := ctx.Err(); err != nil {
+						result.Err = err
+					} else {
+						result.Photo, result.Err = bp.prove(ctx, job, &predicates)
+					}
+
+					select {
+					case results <- result:
+					case <-ctx.Done():
+						return
+					}
+					pending.Wait()
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// Prove a slice of jobs and return their results in the same order.
+// Jobs that were not started before ctx was cancelled report ctx.Err().
+func (bp BatchProver) ProveAll(ctx context.Context, jobs []Job) []Result {
+	ordered := make([]Result, len(jobs))
+	for i, job := range jobs {
+		ordered[i] = Result{Id: job.Id}
+	}
+
+	// Jobs are identified by their index while they are in the pool
+	queue := make(chan Job)
+	go func() {
+		defer close(queue)
+		for i, job := range jobs {
+			job.Id = strconv.Itoa(i)
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	done := make([]bool, len(jobs))
+	for result := range bp.Prove(ctx, queue) {
+		i, _ := strconv.Atoi(result.Id)
+		ordered[i].Photo, ordered[i].Err = result.Photo, result.Err
+		done[i] = true
+	}
+
+	for i := range ordered {
+		if !done[i] {
+			ordered[i].Err = ctx.Err()
+		}
+	}
+
+	return ordered
+}
+
+func (bp BatchProver) prove(ctx context.Context, job Job, predicates *compiled) (Photograph, error) {
+	var err error
+	photo := job.Photo
+
+	// Share one compiled circuit between every job of the same mode & policy
+	if photo.ProvingKeys.Compliance_Predicate == nil {
+		photo.ProvingKeys.Compliance_Predicate, err = Step(ctx, Phase_Compile, func() (constraint.ConstraintSystem, error) {
+			return predicates.get(photo.ProvingKeys.Mode, photo.ProvingKeys.Policy)
+		})
+		if err != nil {
+			return Photograph{}, err
+		}
+	}
+
+	/* Case 1: prove originality, as in camera.TakePhotograph() */
+	if photo.Proof.PCD_Proof == nil {
+		photo.Proof.PCD_Proof, err = bp.User.Prove(ctx, photo, photo, job.Tr, job.Params)
+		if err != nil {
+			return Photograph{}, err
+		}
+		photo.ProvingKeys.Record(&photo.Proof)
+		return photo, nil
+	}
+
+	/* Case 2: edit and prove the transformation */
+	return bp.User.Edit(ctx, photo, job.Tr, job.Params)
+}
+
+// Compiled circuits shared by the workers of a single Prove() call
+type compiled struct {
+	mu         sync.Mutex
+	predicates map[compiledKey]constraint.ConstraintSystem
+}
+
+// Identifies a circuit by its mode and the transformations & bounds of its policy
+type compiledKey struct {
+	mode   Mode
+	policy string
+}
+
+func (c *compiled) get(mode Mode, policy Policy) (constraint.ConstraintSystem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := compiledKey{mode, fmt.Sprint(policy.Transformations)}
+	if predicate, ok := c.predicates[key]; ok {
+		return predicate, nil
+	}
+
+	predicate, err := Compile(mode, policy)
+	if err != nil {
+		return nil, err
+	}
+	c.predicates[key] = predicate
+
+	return predicate, nil
+}
@@ -0,0 +1,78 @@
+package photoproof
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*------------------------------------------------Provenance Budgets---------------------------------------------*/
+
+/*
+	Each provenance entry's Tr_Bound is the remaining budget of its transformation. Applying a transformation
+	consumes Registration.Cost of its own entry, every other entry is carried over unchanged, and no budget may
+	go below zero. The camera starts every image with the policy's bounds (see Policy.Provenance()), which the first
+	edit asserts of the original, and every later edit starts from the previous one's output (see Check_Input()), so
+	budgets are consumed across the whole chain and an editor cannot raise one before spending it.
+*/
+
+// Returns img with the budget consumed by applying the named transformation with params,
+// or ErrBudgetExceeded if its remaining budget is too small.
+func (policy Policy) Consume(img image.Image, name string, params Parameters) (image.Image, error) {
+	slot := policy.Slot(name)
+	if slot < 0 {
+		return image.Image{}, fmt.Errorf("%w: %q by policy %q", ErrNotPermitted, name, policy.Name)
+	}
+	reg, _ := Lookup(name)
+
+	provenance := img.Provenance
+	cost := reg.cost(params)
+	if cost > provenance[slot].Tr_Bound {
+		return image.Image{}, fmt.Errorf("%w: %s costs %d, %d left", ErrBudgetExceeded, name, cost, provenance[slot].Tr_Bound)
+	}
+	provenance[slot].Tr_Bound -= cost
+
+	img.SetProvenance(provenance)
+	return img, nil
+}
+
+// [Gnark-friendly] Assert that Z_out's provenance is Z_in's, minus the budget consumed by the flagged transformation.
+// Holds for proofs of originality too, since "identity" consumes nothing.
+func Check_Provenance(api frontend.API, circuit *PhotoGnark) {
+	regs := circuit.Policy.Registrations()
+
+	for i := range circuit.Z_in.Img.Provenance {
+		prov_in := circuit.Z_in.Img.Provenance[i]
+		prov_out := circuit.Z_out.Img.Provenance[i]
+
+		// Entries never move
+		api.AssertIsEqual(prov_in.Tr_Name, prov_out.Tr_Name)
+
+		// Only the applied transformation consumes budget. Unused entries are carried over as they are.
+		consumed := frontend.Variable(0)
+		if i < len(regs) {
+			consumed = api.Mul(circuit.Tr_Flags[i], regs[i].fr_cost(api, circuit.Tr_Params))
+		}
+		api.AssertIsEqual(prov_out.Tr_Bound, api.Sub(prov_in.Tr_Bound, consumed))
+
+		// A negative budget wraps around the field, so it does not fit in 64 bits
+		api.ToBinary(prov_out.Tr_Bound, 64)
+	}
+}
+
+// Out-of-circuit cost of one application, 1 if the transformation does not define it
+func (reg Registration) cost(params Parameters) uint64 {
+	if reg.Cost == nil {
+		return 1
+	}
+	return reg.Cost(params)
+}
+
+// In-circuit cost of one application, 1 if the transformation does not define it
+func (reg Registration) fr_cost(api frontend.API, params []frontend.Variable) frontend.Variable {
+	if reg.Fr_Cost == nil {
+		return 1
+	}
+	return reg.Fr_Cost(api, params)
+}
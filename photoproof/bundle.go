@@ -0,0 +1,240 @@
+package photoproof
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*--------------------------------------------------Proof Bundle-------------------------------------------------*/
+
+// A Bundle is the shareable form of a Photograph: Z, its PCD proof and the editor's signature.
+// The Admin's keys are not included, a bundle refers to its verifying key by fingerprint and verifiers
+// use the keys they trust.
+type Bundle struct {
+	Image              image.Image `json:"image"`
+	Original_PublicKey []byte      `json:"original_public_key,omitempty"` // Camera's key, left out by HideCamera()
+	Original_Signature []byte      `json:"original_signature,omitempty"`
+	Original_Hash      []byte      `json:"original_hash"`
+
+	Capture   image.Capture          `json:"capture"`
+	Timestamp *image.Timestamp_Token `json:"timestamp,omitempty"` // Set if a timestamp authority set the capture time
+
+	PCD_Proof []byte `json:"pcd_proof"`
+	Signature []byte `json:"signature,omitempty"`  // Left out with Mode_Hidden_Editor, it would identify the editor
+	PublicKey []byte `json:"public_key,omitempty"` // Editor's public key, PublicKey_out, left out with Mode_Hidden_Editor
+	Cameras   int    `json:"cameras,omitempty"`    // Number of authorised cameras the proof was made against
+
+	Revocation_Epoch uint64 `json:"revocation_epoch,omitempty"` // Epoch of the revocation list the proof was made against
+	Editors          int    `json:"editors,omitempty"`          // Number of authorised editors the proof was made against
+
+	Area *image.Area `json:"area,omitempty"` // With Mode_Region, the only pixels proven to be the original's
+
+	Input_Commitment []byte        `json:"input_commitment,omitempty"` // Commitment to the image the last edit was applied to
+	Previous         []Bundle_Edit `json:"previous,omitempty"`         // Proofs of the edits before the last one, oldest first
+
+	VerifyingKey_Fingerprint string `json:"verifying_key_fingerprint"`
+}
+
+// The shareable form of an Edit_Proof
+type Bundle_Edit struct {
+	PCD_Proof []byte `json:"pcd_proof"`
+	PublicKey []byte `json:"public_key,omitempty"` // Left out with Mode_Hidden_Editor
+	Cameras   int    `json:"cameras,omitempty"`
+
+	Revocation_Epoch uint64 `json:"revocation_epoch,omitempty"`
+	Editors          int    `json:"editors,omitempty"`
+
+	Input_Commitment []byte       `json:"input_commitment"`
+	Commitment       []byte       `json:"commitment"`
+	Image            *image.Image `json:"image,omitempty"` // Set with Mode_Public
+}
+
+// Returns the bundle of a proven photograph
+func (photo Photograph) Bundle() (Bundle, error) {
+	if photo.Proof.PCD_Proof == nil {
+		return Bundle{}, ErrNoProof
+	}
+
+	proof, err := gnarkBytes(photo.Proof.PCD_Proof)
+	if err != nil {
+		return Bundle{}, err
+	}
+	fingerprint, err := photo.VerifyingKeys.Fingerprint()
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	var original_pk []byte
+	if photo.Z.Original_PublicKey != nil {
+		original_pk = photo.Z.Original_PublicKey.Bytes()
+	}
+
+	// A hidden editor's signature and key never leave the editor, the proof shows they are one of the Admin's
+	sig_out, pk_out := photo.Proof.Signature, []byte(nil)
+	if photo.Proof.PublicKey != nil {
+		pk_out = photo.Proof.PublicKey.Bytes()
+	}
+	if photo.VerifyingKeys.Mode.Hidden_Editor() {
+		sig_out, pk_out = nil, nil
+	}
+
+	var previous []Bundle_Edit
+	for _, edit := range photo.Proof.Previous {
+		proof, err := gnarkBytes(edit.PCD_Proof)
+		if err != nil {
+			return Bundle{}, err
+		}
+		var pk []byte
+		if edit.PublicKey != nil && !photo.VerifyingKeys.Mode.Hidden_Editor() {
+			pk = edit.PublicKey.Bytes()
+		}
+		previous = append(previous, Bundle_Edit{
+			PCD_Proof:        proof,
+			PublicKey:        pk,
+			Cameras:          edit.Cameras,
+			Revocation_Epoch: edit.Revocation_Epoch,
+			Editors:          edit.Editors,
+			Input_Commitment: edit.Input_Commitment,
+			Commitment:       edit.Commitment,
+			Image:            edit.Image,
+		})
+	}
+
+	return Bundle{
+		Image:                    photo.Z.Img,
+		Original_PublicKey:       original_pk,
+		Original_Signature:       photo.Z.Original_Signature,
+		Original_Hash:            photo.Z.Original_Hash,
+		Capture:                  photo.Z.Capture,
+		Timestamp:                photo.Z.Timestamp,
+		PCD_Proof:                proof,
+		Signature:                sig_out,
+		PublicKey:                pk_out,
+		Cameras:                  photo.Proof.Cameras,
+		Revocation_Epoch:         photo.Proof.Revocation_Epoch,
+		Editors:                  photo.Proof.Editors,
+		Area:                     photo.Proof.Area,
+		Input_Commitment:         photo.Proof.Input_Commitment,
+		Previous:                 previous,
+		VerifyingKey_Fingerprint: fingerprint,
+	}, nil
+}
+
+// Returns the photograph of the bundle, with the given keys.
+// Returns ErrKeyMismatch if the bundle was not proven for the verifying key.
+func (bundle Bundle) Photograph(pk ProverKeys, vk VerifierKeys) (Photograph, error) {
+	fingerprint, err := vk.Fingerprint()
+	if err != nil {
+		return Photograph{}, err
+	}
+	if fingerprint != bundle.VerifyingKey_Fingerprint {
+		return Photograph{}, fmt.Errorf("%w: bundle was proven for verifying key %s", ErrKeyMismatch, bundle.VerifyingKey_Fingerprint)
+	}
+
+	var original_pk signature.PublicKey
+	if len(bundle.Original_PublicKey) > 0 {
+		if original_pk, err = DecodePublicKey(bundle.Original_PublicKey); err != nil {
+			return Photograph{}, err
+		}
+	}
+	var pk_out signature.PublicKey
+	if len(bundle.PublicKey) > 0 {
+		if pk_out, err = DecodePublicKey(bundle.PublicKey); err != nil {
+			return Photograph{}, err
+		}
+	}
+
+	proof, err := readProof(bundle.PCD_Proof)
+	if err != nil {
+		return Photograph{}, err
+	}
+
+	var previous []Edit_Proof
+	for _, edit := range bundle.Previous {
+		proof, err := readProof(edit.PCD_Proof)
+		if err != nil {
+			return Photograph{}, err
+		}
+		var pk signature.PublicKey
+		if len(edit.PublicKey) > 0 {
+			if pk, err = DecodePublicKey(edit.PublicKey); err != nil {
+				return Photograph{}, err
+			}
+		}
+		previous = append(previous, Edit_Proof{
+			PCD_Proof:        proof,
+			PublicKey:        pk,
+			Cameras:          edit.Cameras,
+			Revocation_Epoch: edit.Revocation_Epoch,
+			Editors:          edit.Editors,
+			Input_Commitment: edit.Input_Commitment,
+			Commitment:       edit.Commitment,
+			Image:            edit.Image,
+		})
+	}
+
+	return Photograph{
+		Z: image.Z{
+			Img:                bundle.Image,
+			Original_PublicKey: original_pk,
+			Original_Signature: bundle.Original_Signature,
+			Original_Hash:      bundle.Original_Hash,
+			Capture:            bundle.Capture,
+			Timestamp:          bundle.Timestamp,
+		},
+		Proof: Proof{
+			PCD_Proof: proof,
+			Signature: bundle.Signature,
+			PublicKey: pk_out,
+			Cameras:   bundle.Cameras,
+
+			Revocation_Epoch: bundle.Revocation_Epoch,
+			Editors:          bundle.Editors,
+			Area:             bundle.Area,
+
+			Input_Commitment: bundle.Input_Commitment,
+			Previous:         previous,
+		},
+		ProvingKeys:   pk,
+		VerifyingKeys: vk,
+	}, nil
+}
+
+// Decode a PCD proof written by gnarkBytes()
+func readProof(data []byte) (groth16.Proof, error) {
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(data)); err != nil {
+		return nil, Wrap(ErrDecode, err)
+	}
+	return proof, nil
+}
+
+// Returns the bundle without the camera's public key and original signature, for publishing a photograph
+// proven with Mode_Hidden_Camera: it still verifies, against the root of the Admin's cameras only.
+// Editors need the full bundle, since they prove that the camera is one of the Admin's.
+func (bundle Bundle) HideCamera() Bundle {
+	bundle.Original_PublicKey = nil
+	bundle.Original_Signature = nil
+	return bundle
+}
+
+// Write the bundle as JSON
+func (bundle Bundle) WriteTo(w io.Writer) (int64, error) {
+	return writeJSON(w, bundle)
+}
+
+// Read a bundle written by Bundle.WriteTo()
+func ReadBundle(r io.Reader) (Bundle, error) {
+	var bundle Bundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return Bundle{}, Wrap(ErrDecode, err)
+	}
+	return bundle, nil
+}
@@ -0,0 +1,265 @@
+package photoproof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*---------------------------------------------------Custody Log-------------------------------------------------*/
+
+/*
+	The final PCD proof shows that a photograph descends from an original through permissible edits, but not
+	through which ones. A Custody_Log keeps every step instead, for whoever has to reconstruct who did what:
+
+		entry 0      the capture, signed by the camera
+		entry i > 0  an edit, signed by its editor
+
+	Every entry holds the step's proven bundle, the commitment to its output image and the digest of the entry
+	before it, and is signed by the key that made the step. Entries are only ever appended, changing or removing
+	one breaks the digest chain.
+
+	The log is optional and identifies every editor, it should not travel with photographs proven with
+	Mode_Hidden_Editor that are meant to stay anonymous.
+*/
+
+// Transformation name of a custody log's first entry
+const Custody_Capture = "capture"
+
+// One step of a photograph's custody
+type Custody_Entry struct {
+	Step           int             `json:"step"`
+	Transformation string          `json:"transformation"` // Custody_Capture for the first entry
+	Params         json.RawMessage `json:"params,omitempty"`
+	Editor         []byte          `json:"editor"`     // Public key of the camera or editor that made the step
+	Commitment     []byte          `json:"commitment"` // image.ImageCommitment() of the step's output
+	Timestamp      time.Time       `json:"timestamp"`
+	Previous       []byte          `json:"previous,omitempty"` // Digest of the previous entry
+	Bundle         Bundle          `json:"bundle"`             // The step's output and its proof
+	Signature      []byte          `json:"signature"`          // Editor's signature of the entry's digest
+}
+
+// An append-only log of a photograph's custody
+type Custody_Log struct {
+	Entries []Custody_Entry `json:"entries"`
+}
+
+// Returns a new custody log of a proven photograph, whose first entry is its capture by user, the camera
+func (photo Photograph) StartCustody(user User) (*Custody_Log, error) {
+	log := &Custody_Log{Entries: []Custody_Entry{}}
+	if err := log.Append(user, photo, Custody_Capture, nil); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// Append the step that produced the proven photograph, with the named transformation and params, signed by user.
+// The log is not changed if the step cannot be recorded.
+func (log *Custody_Log) Append(user User, photo Photograph, transformation string, params Parameters) error {
+	bundle, err := photo.Bundle()
+	if err != nil {
+		return err
+	}
+
+	entry := Custody_Entry{
+		Step:           len(log.Entries),
+		Transformation: transformation,
+		Editor:         user.PublicKey.Bytes(),
+		Commitment:     image.ImageCommitment(photo.Z.Img),
+		Timestamp:      time.Now().UTC(),
+		Bundle:         bundle,
+	}
+	if params != nil {
+		if entry.Params, err = json.Marshal(params); err != nil {
+			return Wrap(ErrInvalidParameters, err)
+		}
+	}
+	if len(log.Entries) > 0 {
+		if entry.Previous, err = log.Entries[len(log.Entries)-1].Digest(); err != nil {
+			return err
+		}
+	}
+
+	if entry.Signature, err = user.signMessage(image.Hash_MiMC, Signed_Message{Custody: &entry}); err != nil {
+		return err
+	}
+
+	log.Entries = append(log.Entries, entry)
+	return nil
+}
+
+// Returns a copy of the log, which can be appended to without changing the original
+func (log *Custody_Log) Clone() *Custody_Log {
+	return &Custody_Log{Entries: append([]Custody_Entry{}, log.Entries...)}
+}
+
+// Returns the digest the entry's editor signs: SHA-256 of the entry without its signature, reduced to a
+// BN254 field element so that it can be signed like an image hash
+func (entry Custody_Entry) Digest() ([]byte, error) {
+	entry.Signature = nil
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	var digest fr.Element
+	digest.SetBytes(sum[:])
+	return digest.Marshal(), nil
+}
+
+// Write the log as JSON
+func (log Custody_Log) WriteTo(w io.Writer) (int64, error) {
+	return writeJSON(w, log)
+}
+
+// Read a log written by Custody_Log.WriteTo()
+func ReadCustodyLog(r io.Reader) (*Custody_Log, error) {
+	var log Custody_Log
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, Wrap(ErrDecode, err)
+	}
+	return &log, nil
+}
+
+/*-------------------------------------------------Custody Verdict-----------------------------------------------*/
+
+// The outcome of verifying a custody log
+type Custody_Verdict struct {
+	Valid bool           `json:"valid"` // Every link is valid
+	Links []Link_Verdict `json:"links"`
+}
+
+// The outcome of verifying one entry of a custody log
+type Link_Verdict struct {
+	Step           int       `json:"step"`
+	Transformation string    `json:"transformation"`
+	Editor         string    `json:"editor"` // Fingerprint of the editor's key
+	Timestamp      time.Time `json:"timestamp"`
+	Valid          bool      `json:"valid"`
+	Error          string    `json:"error,omitempty"` // Why the link is invalid
+	Verdict        Verdict   `json:"verdict"`         // Verdict of the step's bundle
+
+	Err error `json:"-"` // Same as Error, for errors.Is()
+}
+
+/*
+Verify every entry of the log with the trusted verifier keys. Each link is checked on its own, so one broken link
+does not hide the others:
+  - it follows the previous entry (step number and digest), and is signed by its editor,
+  - its bundle is valid, and is the image committed to, signed by the same editor,
+  - it descends from the same original as the first entry, and is proven from the previous entry's image,
+  - applying its transformation and parameters to the previous entry's image gives its image, budgets included.
+*/
+func VerifyCustody(log Custody_Log, trusted ...VerifierKeys) Custody_Verdict {
+	verdict := Custody_Verdict{Valid: len(log.Entries) > 0, Links: []Link_Verdict{}}
+	for i := range log.Entries {
+		link := verifyLink(log, i, trusted)
+		verdict.Valid = verdict.Valid && link.Valid
+		verdict.Links = append(verdict.Links, link)
+	}
+	return verdict
+}
+
+func verifyLink(log Custody_Log, i int, trusted []VerifierKeys) Link_Verdict {
+	entry := log.Entries[i]
+	link := Link_Verdict{Step: entry.Step, Transformation: entry.Transformation, Editor: Fingerprint(entry.Editor), Timestamp: entry.Timestamp}
+
+	// The entry follows the previous one
+	var previous []byte
+	if i > 0 {
+		var err error
+		if previous, err = log.Entries[i-1].Digest(); err != nil {
+			return link.invalid(err)
+		}
+	}
+	if entry.Step != i || !bytes.Equal(entry.Previous, previous) {
+		return link.invalid(fmt.Errorf("%w: entry %d does not follow entry %d", ErrBrokenCustody, entry.Step, i-1))
+	}
+	if (i == 0) != (entry.Transformation == Custody_Capture) {
+		return link.invalid(fmt.Errorf("%w: only the first entry is a %s", ErrBrokenCustody, Custody_Capture))
+	}
+
+	// The entry is signed by its editor, who also signed the step's output
+	editor, err := DecodePublicKey(entry.Editor)
+	if err != nil {
+		return link.invalid(err)
+	}
+	digest, err := entry.Digest()
+	if err != nil {
+		return link.invalid(err)
+	}
+	if err := VerifySignature(image.Hash_MiMC, editor, digest, entry.Signature); err != nil {
+		return link.invalid(err)
+	}
+	signer := entry.Bundle.PublicKey
+	if i == 0 && len(entry.Bundle.Original_PublicKey) > 0 {
+		signer = entry.Bundle.Original_PublicKey
+	}
+	if len(signer) > 0 && !bytes.Equal(signer, entry.Editor) {
+		return link.invalid(fmt.Errorf("%w: entry %d is signed by another key than its bundle", ErrKeyMismatch, i))
+	}
+
+	// The step's output is proven, and is the committed image
+	link.Verdict = VerifyBundle(entry.Bundle, trusted...)
+	if !link.Verdict.Valid {
+		return link.invalid(link.Verdict.Err)
+	}
+	if !bytes.Equal(entry.Commitment, image.ImageCommitment(entry.Bundle.Image)) {
+		return link.invalid(fmt.Errorf("%w: entry %d's image is not the committed one", ErrImageMismatch, i))
+	}
+	if i == 0 {
+		link.Valid = true
+		return link
+	}
+
+	// The step is the recorded transformation of the previous entry's image
+	first, prev := log.Entries[0].Bundle, log.Entries[i-1].Bundle
+	if !bytes.Equal(entry.Bundle.Original_Hash, first.Original_Hash) {
+		return link.invalid(fmt.Errorf("%w: entry %d descends from another original", ErrImageMismatch, i))
+	}
+	if !bytes.Equal(entry.Bundle.Input_Commitment, log.Entries[i-1].Commitment) {
+		return link.invalid(fmt.Errorf("%w: entry %d is not proven from entry %d's image", ErrImageMismatch, i, i-1))
+	}
+	img_out, err := replay(prev.Image, entry, trusted)
+	if err != nil {
+		return link.invalid(err)
+	}
+	if !bytes.Equal(entry.Commitment, image.ImageCommitment(img_out)) {
+		return link.invalid(fmt.Errorf("%w: entry %d is not %s of entry %d", ErrImageMismatch, i, entry.Transformation, i-1))
+	}
+
+	link.Valid = true
+	return link
+}
+
+// Returns img_in edited with the entry's transformation and parameters, as User.Edit() does
+func replay(img_in image.Image, entry Custody_Entry, trusted []VerifierKeys) (image.Image, error) {
+	vk, err := findTrusted(entry.Bundle.VerifyingKey_Fingerprint, trusted)
+	if err != nil {
+		return image.Image{}, err
+	}
+	reg, ok := Lookup(entry.Transformation)
+	if !ok {
+		return image.Image{}, fmt.Errorf("%w: %q", ErrUnknownTransformation, entry.Transformation)
+	}
+	params, err := ParseParameters(entry.Transformation, entry.Params)
+	if err != nil {
+		return image.Image{}, err
+	}
+
+	return vk.Policy.Consume(reg.Transformation.Apply(img_in, &params), entry.Transformation, params)
+}
+
+func (link Link_Verdict) invalid(err error) Link_Verdict {
+	link.Valid = false
+	link.Error = err.Error()
+	link.Err = err
+	return link
+}
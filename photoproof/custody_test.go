@@ -0,0 +1,62 @@
+package photoproof
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+// A log of a capture and two edits verifies, and tampering with the middle link breaks it and the one after it
+func TestVerifyCustody(t *testing.T) {
+	img := testImage(t, "random")
+	img.Metadata.GPS = &image.GPS{Latitude: 40_443_322, Longitude: -79_943_041}
+	cam, photo := testOriginal(t, Mode_Public, DefaultPolicy(), img)
+	photo = testProven(t, photo)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if photo.Custody, err = photo.StartCustody(cam); err != nil {
+		t.Fatal(err)
+	}
+	redacted, err := editor.Edit(context.Background(), photo, Redact_GPS_Tr{}, Redact_GPS_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := editor.Edit(context.Background(), redacted, Identity_Tr{}, Identity_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := *edited.Custody
+	if verdict := VerifyCustody(log, photo.VerifyingKeys); !verdict.Valid || len(verdict.Links) != 3 {
+		t.Fatalf("custody of a capture and two edits: valid %t, %d links", verdict.Valid, len(verdict.Links))
+	}
+
+	// The middle link claims another transformation than the one its editor signed
+	tampered := log.Clone()
+	tampered.Entries[1].Transformation = "identity"
+	verdict := VerifyCustody(*tampered, photo.VerifyingKeys)
+	if verdict.Valid || !verdict.Links[0].Valid {
+		t.Fatalf("custody with a tampered middle link: valid %t, capture valid %t", verdict.Valid, verdict.Links[0].Valid)
+	}
+	if !errors.Is(verdict.Links[1].Err, ErrInvalidSignature) || !errors.Is(verdict.Links[2].Err, ErrBrokenCustody) {
+		t.Fatalf("custody with a tampered middle link: link errors %v and %v, expected %v and %v", verdict.Links[1].Err, verdict.Links[2].Err, ErrInvalidSignature, ErrBrokenCustody)
+	}
+
+	// ... or is re-signed by its editor, which still does not replay on the capture
+	resigned, err := tampered.Entries[1].Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tampered.Entries[1].Signature, err = editor.SignDigest(image.Hash_MiMC, resigned); err != nil {
+		t.Fatal(err)
+	}
+	verdict = VerifyCustody(*tampered, photo.VerifyingKeys)
+	if verdict.Valid || !errors.Is(verdict.Links[1].Err, ErrImageMismatch) {
+		t.Fatalf("custody with a re-signed middle link: valid %t, link error %v, expected %v", verdict.Valid, verdict.Links[1].Err, ErrImageMismatch)
+	}
+}
@@ -1,47 +1,87 @@
 package photoproof
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/drakstik/PhotoGnark_ACDF/image"
 )
 
 // Input: Photograph, transformation, parameters
 // Output: Photograph with proof that the transformation occured in compliance with Admin's circuit
-func (user User) Edit(photo_in Photograph, tr Transformation, params Parameters) (Photograph, error) {
-	fmt.Println("********Editor********")
+// photo_in is verified against its VerifyingKeys first, and an invalid one is not edited: the verdict's error is
+// returned. When photo_in was edited already, photo_out carries the proofs of its edits too (see Proof.Previous).
+// Proving stops with ctx.Err() when ctx is cancelled.
+func (user User) Edit(ctx context.Context, photo_in Photograph, tr Transformation, params Parameters) (Photograph, error) {
+	Logger().Debug("editing photograph", "transformation", tr.GetName())
+
+	// Only a photograph that verifies against the Admin's verifier keys is edited
+	bundle_in, err := photo_in.Bundle()
+	if err != nil {
+		return Photograph{}, err
+	}
+	if verdict := VerifyBundle(bundle_in, photo_in.VerifyingKeys); !verdict.Valid {
+		return Photograph{}, verdict.Err
+	}
+
+	// Some transformations only apply to some images, e.g. redact_gps to images with a location
+	if reg, ok := Lookup(tr.GetName()); ok && reg.Applies != nil {
+		if err := reg.Applies(photo_in.Z.Img, params); err != nil {
+			return Photograph{}, err
+		}
+	}
+
 	img_out := tr.Apply(photo_in.Z.Img, &params) // Apply the transformation to the image
 
-	signature_out, err := user.Sign(img_out)
+	// Consume the transformation's provenance budget
+	img_out, err = photo_in.ProvingKeys.Policy.Consume(img_out, tr.GetName(), params)
+	if err != nil {
+		return Photograph{}, err
+	}
+
+	signature_out, err := user.Sign(photo_in.ProvingKeys.Mode.Hash(), img_out)
 	if err != nil {
-		fmt.Println("[Edit] Signing image failed")
 		return Photograph{}, err
 	}
 
 	photo_out := Photograph{
 		Z: image.Z{
 			Img:                img_out,
-			Original_PublicKey: photo_in.ProvingKeys.Original_PublicKey,
+			Original_PublicKey: photo_in.Z.Original_PublicKey,
 			Original_Signature: photo_in.Z.Original_Signature,
 			Original_Hash:      photo_in.Z.Original_Hash,
+			Capture:            photo_in.Z.Capture,
+			Timestamp:          photo_in.Z.Timestamp,
 		},
 		Proof: Proof{
 			PCD_Proof: nil, // photo_out must now get proven compliant
 			Signature: signature_out,
+			PublicKey: user.PublicKey,
+
+			Input_Commitment: image.ImageCommitment(photo_in.Z.Img),
+			Previous:         photo_in.Edits(),
 		},
 		ProvingKeys:   photo_in.ProvingKeys,
 		VerifyingKeys: photo_in.VerifyingKeys,
 	}
 
 	// Prove photo_out is compliant with Admin's circuit
-	proof_out, err := user.Prove(photo_in, photo_out, tr, params)
+	proof_out, err := user.Prove(ctx, photo_in, photo_out, tr, params)
 	if err != nil {
-		fmt.Println("[Edit] Proving image failed\n" + err.Error())
+		Logger().Warn("proving edited photograph failed", "transformation", tr.GetName(), "error", err)
 		return Photograph{}, err
 	}
 
 	// Set the PCD proof, claiming compliance to the
 	photo_out.Proof.PCD_Proof = proof_out
+	photo_in.ProvingKeys.Record(&photo_out.Proof)
+
+	// Record the step in the photograph's custody log, if it keeps one
+	if photo_in.Custody != nil {
+		photo_out.Custody = photo_in.Custody.Clone()
+		if err := photo_out.Custody.Append(user, photo_out, tr.GetName(), params); err != nil {
+			return Photograph{}, err
+		}
+	}
 
-	return photo_out, err
+	return photo_out, nil
 }
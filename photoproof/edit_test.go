@@ -0,0 +1,95 @@
+package photoproof
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+func TestEdit(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "random"))
+	photo = testProven(t, photo)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, err := editor.Edit(context.Background(), photo, Identity_Tr{}, Identity_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(edited, edited.VerifyingKeys); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// An edited photograph is edited again, and carries the proofs of both edits
+func TestEdit_Chain(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "random"))
+	photo = testProven(t, photo)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited := photo
+	for range 2 {
+		if edited, err = editor.Edit(context.Background(), edited, Identity_Tr{}, Identity_Tr_Params{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Verify(edited, edited.VerifyingKeys); err != nil {
+		t.Fatal(err)
+	}
+
+	// The edits travel in the bundle
+	bundle, err := edited.Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict := VerifyBundle(bundle, edited.VerifyingKeys); !verdict.Valid || len(bundle.Previous) != 1 {
+		t.Fatalf("bundle of an edit of an edit: valid %t, %d previous edits, error %v", verdict.Valid, len(bundle.Previous), verdict.Err)
+	}
+
+	// Each edit's output must be the next one's input
+	broken := edited
+	broken.Proof.Previous = slices.Clone(edited.Proof.Previous)
+	broken.Proof.Previous[0].Commitment = image.ImageCommitment(testImage(t, "black"))
+	if _, err := Verify(broken, broken.VerifyingKeys); !errors.Is(err, ErrImageMismatch) {
+		t.Fatalf("verifying a broken chain of edits returned %v, expected %v", err, ErrImageMismatch)
+	}
+
+	// ... and every edit must be proven
+	broken.Proof.Previous = nil
+	if _, err := Verify(broken, broken.VerifyingKeys); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("verifying an edit without its previous edit returned %v, expected %v", err, ErrInvalidProof)
+	}
+}
+
+// A photograph that does not verify is not edited
+func TestEdit_InvalidInput(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "random"))
+	photo = testProven(t, photo)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pixels that differ from the proven ones
+	tampered := photo
+	tampered.Z.Img.Pxls[0].RGB[0] ^= 0xff
+	tampered.Z.Img.PxlBytes = image.BigInt_to_Fr_Bytes(tampered.Z.Img)
+	if _, err := editor.Edit(context.Background(), tampered, Identity_Tr{}, Identity_Tr_Params{}); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("editing a tampered photograph returned %v, expected %v", err, ErrInvalidProof)
+	}
+
+	// No proof at all
+	tampered = photo
+	tampered.Proof.PCD_Proof = nil
+	if _, err := editor.Edit(context.Background(), tampered, Identity_Tr{}, Identity_Tr_Params{}); !errors.Is(err, ErrNoProof) {
+		t.Fatalf("editing an unproven photograph returned %v, expected %v", err, ErrNoProof)
+	}
+}
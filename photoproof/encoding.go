@@ -0,0 +1,206 @@
+package photoproof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+/*--------------------------------------------------Key Encoding-------------------------------------------------*/
+
+/*
+	Prover and verifier keys are stored as JSON, so the Admin's setup (mode, policy, original public key) travels
+	with the PCD key it describes. Gnark's binary encoding of the PCD key is embedded as base64.
+	The compiled circuit is not stored, provers recompile it from the mode and policy.
+*/
+
+type proverKeysJSON struct {
+	Mode               Mode            `json:"mode"`
+	Policy             Policy          `json:"policy"`
+	Original_PublicKey []byte          `json:"original_public_key"`
+	Cameras            Key_Set         `json:"cameras"`
+	Revocations        Revocation_List `json:"revocations"`
+	Editors            Key_Set         `json:"editors"`
+	ProvingKey         []byte          `json:"proving_key"`
+}
+
+type verifierKeysJSON struct {
+	Mode               Mode            `json:"mode"`
+	Policy             Policy          `json:"policy"`
+	Original_PublicKey []byte          `json:"original_public_key"`
+	Cameras            Key_Set         `json:"cameras"`
+	Revocations        Revocation_List `json:"revocations"`
+	Editors            Key_Set         `json:"editors"`
+	VerifyingKey       []byte          `json:"verifying_key"`
+	Revocation_Window  uint64          `json:"revocation_window,omitempty"`
+}
+
+// Returns the hex encoded SHA-256 of data, used to identify keys
+func Fingerprint(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+// Returns the fingerprint of the verifying key, which bundles refer to
+func (keys VerifierKeys) Fingerprint() (string, error) {
+	data, err := gnarkBytes(keys.VerifyingKey)
+	if err != nil {
+		return "", err
+	}
+	return Fingerprint(data), nil
+}
+
+// Write the prover keys as JSON
+func (keys ProverKeys) WriteTo(w io.Writer) (int64, error) {
+	provingKey, err := gnarkBytes(keys.ProvingKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return writeJSON(w, proverKeysJSON{
+		Mode:               keys.Mode,
+		Policy:             keys.Policy,
+		Original_PublicKey: keys.Original_PublicKey.Bytes(),
+		Cameras:            keys.Cameras,
+		Revocations:        keys.Revocations,
+		Editors:            keys.Editors,
+		ProvingKey:         provingKey,
+	})
+}
+
+// Read prover keys written by ProverKeys.WriteTo()
+func ReadProverKeys(r io.Reader) (ProverKeys, error) {
+	var encoded proverKeysJSON
+	if err := json.NewDecoder(r).Decode(&encoded); err != nil {
+		return ProverKeys{}, Wrap(ErrDecode, err)
+	}
+	if err := encoded.Policy.Validate(); err != nil {
+		return ProverKeys{}, err
+	}
+
+	original_pk, err := DecodePublicKey(encoded.Original_PublicKey)
+	if err != nil {
+		return ProverKeys{}, err
+	}
+	cameras, err := decodeCameras(encoded.Mode, encoded.Cameras, original_pk)
+	if err != nil {
+		return ProverKeys{}, err
+	}
+	if err := encoded.Revocations.Validate(); err != nil {
+		return ProverKeys{}, err
+	}
+	if err := encoded.Editors.Validate(); err != nil {
+		return ProverKeys{}, err
+	}
+
+	provingKey := groth16.NewProvingKey(ecc.BN254)
+	if _, err := provingKey.ReadFrom(bytes.NewReader(encoded.ProvingKey)); err != nil {
+		return ProverKeys{}, Wrap(ErrDecode, err)
+	}
+
+	return ProverKeys{
+		ProvingKey:         provingKey,
+		Original_PublicKey: original_pk,
+		Mode:               encoded.Mode,
+		Policy:             encoded.Policy,
+		Cameras:            cameras,
+		Revocations:        encoded.Revocations,
+		Editors:            encoded.Editors,
+	}, nil
+}
+
+// Write the verifier keys as JSON
+func (keys VerifierKeys) WriteTo(w io.Writer) (int64, error) {
+	verifyingKey, err := gnarkBytes(keys.VerifyingKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return writeJSON(w, verifierKeysJSON{
+		Mode:               keys.Mode,
+		Policy:             keys.Policy,
+		Original_PublicKey: keys.Original_PublicKey.Bytes(),
+		Cameras:            keys.Cameras,
+		Revocations:        keys.Revocations,
+		Editors:            keys.Editors,
+		VerifyingKey:       verifyingKey,
+		Revocation_Window:  keys.Revocation_Window,
+	})
+}
+
+// Read verifier keys written by VerifierKeys.WriteTo()
+func ReadVerifierKeys(r io.Reader) (VerifierKeys, error) {
+	var encoded verifierKeysJSON
+	if err := json.NewDecoder(r).Decode(&encoded); err != nil {
+		return VerifierKeys{}, Wrap(ErrDecode, err)
+	}
+	if err := encoded.Policy.Validate(); err != nil {
+		return VerifierKeys{}, err
+	}
+
+	original_pk, err := DecodePublicKey(encoded.Original_PublicKey)
+	if err != nil {
+		return VerifierKeys{}, err
+	}
+	cameras, err := decodeCameras(encoded.Mode, encoded.Cameras, original_pk)
+	if err != nil {
+		return VerifierKeys{}, err
+	}
+	if err := encoded.Revocations.Validate(); err != nil {
+		return VerifierKeys{}, err
+	}
+	if err := encoded.Editors.Validate(); err != nil {
+		return VerifierKeys{}, err
+	}
+
+	verifyingKey := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := verifyingKey.ReadFrom(bytes.NewReader(encoded.VerifyingKey)); err != nil {
+		return VerifierKeys{}, Wrap(ErrDecode, err)
+	}
+
+	return VerifierKeys{
+		VerifyingKey:       verifyingKey,
+		Original_PublicKey: original_pk,
+		Mode:               encoded.Mode,
+		Policy:             encoded.Policy,
+		Cameras:            cameras,
+		Revocations:        encoded.Revocations,
+		Editors:            encoded.Editors,
+		Revocation_Window:  encoded.Revocation_Window,
+	}, nil
+}
+
+// Keys written before cameras were registered only authorise the Admin's own key
+func decodeCameras(mode Mode, cameras Key_Set, original_pk signature.PublicKey) (Key_Set, error) {
+	if err := mode.Validate(); err != nil {
+		return Key_Set{}, err
+	}
+	if len(cameras.Keys) == 0 {
+		return NewKeySet(original_pk)
+	}
+	return cameras, cameras.Validate()
+}
+
+// Gnark's binary encoding of a key or proof
+func gnarkBytes(v io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(w io.Writer, v any) (int64, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
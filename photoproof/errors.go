@@ -0,0 +1,54 @@
+package photoproof
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*-----------------------------------------------------Errors----------------------------------------------------*/
+
+// Sentinel errors returned by photoproof and camera. They are always returned, never only logged,
+// and wrap the underlying cause, so callers can use both errors.Is(err, ErrCompile) and errors.As on the cause.
+var (
+	ErrUnknownTransformation = errors.New("photoproof: unknown transformation")
+	ErrInvalidParameters     = errors.New("photoproof: invalid transformation parameters")
+	ErrInvalidPolicy         = errors.New("photoproof: invalid policy")
+	ErrInvalidMode           = errors.New("photoproof: invalid circuit mode")
+	ErrNotPermitted          = errors.New("photoproof: transformation not permitted")
+	ErrBudgetExceeded        = errors.New("photoproof: provenance budget exceeded")
+	ErrPolicyMismatch        = errors.New("photoproof: provenance does not match the policy")
+	ErrInvalidMetadata       = errors.New("photoproof: invalid capture metadata")
+	ErrInvalidSignature      = errors.New("photoproof: invalid signature")
+	ErrInvalidTimestamp      = errors.New("photoproof: invalid timestamp token")
+	ErrKeyMismatch           = errors.New("photoproof: key mismatch")
+	ErrInvalidKeySet         = errors.New("photoproof: invalid key set")
+	ErrNotAuthorised         = errors.New("photoproof: key is not in the authorised key set")
+	ErrRevoked               = errors.New("photoproof: key is revoked")
+	ErrStaleRevocation       = errors.New("photoproof: proof was made against a stale revocation list")
+	ErrImageMismatch         = errors.New("photoproof: image does not match its hash")
+	ErrInvalidArea           = errors.New("photoproof: invalid area")
+	ErrKeyGeneration         = errors.New("photoproof: key generation failed")
+	ErrSign                  = errors.New("photoproof: signing failed")
+	ErrExternalSigner        = errors.New("photoproof: secret key is held by an external signer")
+	ErrCompile               = errors.New("photoproof: circuit compilation failed")
+	ErrSetup                 = errors.New("photoproof: PCD key generation failed")
+	ErrWitness               = errors.New("photoproof: witness creation failed")
+	ErrProve                 = errors.New("photoproof: proving failed")
+	ErrNoProof               = errors.New("photoproof: photograph has no PCD proof")
+	ErrInvalidProof          = errors.New("photoproof: invalid PCD proof")
+	ErrBrokenCustody         = errors.New("photoproof: broken custody log")
+	ErrCeremony              = errors.New("photoproof: invalid ceremony contribution")
+	ErrDecode                = errors.New("photoproof: malformed encoding")
+)
+
+// Returns err wrapped in the sentinel, or nil if err is nil.
+// Context errors are returned as is, so cancellation is never mistaken for a failure.
+func Wrap(sentinel error, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sentinel) || isContextError(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
@@ -1,12 +1,40 @@
 package photoproof
 
 import (
+	"errors"
+
 	"github.com/consensys/gnark/frontend"
 	"github.com/drakstik/PhotoGnark_ACDF/image"
 )
 
 /*--------------------------------------------Identity Transformation--------------------------------------------*/
 
+// "identity" == 0
+const Identity_Id uint64 = 0
+
+func init() {
+	Register(Registration{
+		Name:           "identity",
+		Id:             Identity_Id,
+		Bound:          1,
+		Transformation: Identity_Tr{},
+		Gadget:         Fr_Identity,
+		Nb_Params:      0,
+		Assign: func(params Parameters) ([]frontend.Variable, error) {
+			if _, ok := params.(Identity_Tr_Params); !ok {
+				return nil, errors.New("identity expects Identity_Tr_Params")
+			}
+			return nil, nil
+		},
+		Parse_Params: func(data []byte) (Parameters, error) {
+			return Identity_Tr_Params{}, nil // identity has no parameters
+		},
+		// Re-proving an image does not consume its identity budget
+		Cost:    func(params Parameters) uint64 { return 0 },
+		Fr_Cost: func(api frontend.API, params []frontend.Variable) frontend.Variable { return 0 },
+	})
+}
+
 type Identity_Tr_Params struct {
 }
 
@@ -14,13 +42,8 @@ func (params Identity_Tr_Params) GetName() string {
 	return "identity"
 }
 
-func (params Identity_Tr_Params) ParamsToFr() Fr_Parameters {
-	return Fr_Identity_Tr_Params{}
-}
-
 // An "identity" transformation returns the same image
 type Identity_Tr struct {
-	Flag int8
 }
 
 // Extend Transformation interface
@@ -34,57 +57,26 @@ func (id_tr Identity_Tr) Apply(img image.Image, params *Parameters) image.Image
 	return img
 }
 
-func (id_tr Identity_Tr) ToFr() Fr_Transformation {
-	return &Fr_Identity_Tr{
-		Flag: id_tr.Flag,
-	}
-}
-
 /*------------------------------------Gnark-Friendly Identity Transformation-------------------------------------*/
 
-type Fr_Identity_Tr_Params struct {
-}
-
-// "identity" == 0
-func (params Fr_Identity_Tr_Params) GetParamsId(api frontend.API) frontend.Variable {
-	return 0
-}
-
-// [Gnark-friendly] An "identity" transformation checks if input & output images are equal
-type Fr_Identity_Tr struct {
-	Flag frontend.Variable
-}
-
-// Get the transformation's name as a frontend.Variable
-func (params Fr_Identity_Tr) GetName() frontend.Variable {
-	return frontend.Variable([]byte("identity"))
-}
-
-// Return 1 if this transformation is permissible
-func (tr Fr_Identity_Tr) GetFlag() frontend.Variable {
-	return tr.Flag
-}
-
-// Check that img_in & img_out are equivelant.
+// [Gnark-friendly] An "identity" transformation checks if input & output images are equal.
 // return 0 if unsuccessful, 1 if successful
-func (id_tr Fr_Identity_Tr) Apply(api frontend.API, circuit *PhotoGnark) frontend.Variable {
+func Fr_Identity(api frontend.API, circuit *PhotoGnark, params []frontend.Variable) frontend.Variable {
 	/* PhotoProof paper, Section V-E:
 
 	"Identity transformation checks whether the input and output image are identical.
 	Identical images have the same pixel data as well as the same metadata."
 
 	Since Pxlbytes includes the relevant metadata, we can keep the same hashing algorithms
-	for a quick equality assertion.
+	for a quick equality check.
 	*/
 
-	// First, check if identity provenance bound is correct
-	api.AssertIsEqual(1, circuit.Z_in.Img.Provenance[0].Tr_Bound)
-
-	imgHash_in := image.Fr_ImageHash(api, circuit.Z_in.Img)
-	imgHash_out := image.Fr_ImageHash(api, circuit.Z_out.Img)
+	// Provenance bounds are checked by Check_Provenance(), and are part of the hash
+	imgHash_in := circuit.Hash.Fr_ImageHash(api, circuit.Z_in.Img)
+	imgHash_out := circuit.Hash.Fr_ImageHash(api, circuit.Z_out.Img)
 
-	// Assert that hashes are equal
-	api.AssertIsEqual(imgHash_in, imgHash_out)
+	// Check that hashes are equal
+	hash_ok := api.IsZero(api.Sub(imgHash_in, imgHash_out))
 
-	return 1
+	return hash_ok
 }
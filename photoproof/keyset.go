@@ -0,0 +1,248 @@
+package photoproof
+
+import (
+	"bytes"
+	"fmt"
+
+	out_mimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	eddsa_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+/*-----------------------------------------------------Key Sets--------------------------------------------------*/
+
+/*
+	A Key_Set is a Merkle tree of public keys, e.g. the cameras authorised by the Admin. Its root is a public input
+	of the circuit, and the prover shows in-circuit that a key is one of its leaves, so one setup serves many
+	cameras and the verifier does not need to know which key signed.
+
+	The tree has a fixed depth, compiled into the circuit:
+
+		leaf  = MiMC(A.X, A.Y) of the public key, or 0 for an empty leaf
+		node  = MiMC(left, right)
+*/
+
+// Depth of every key set's Merkle tree, so a key set holds at most 2^Key_Set_Depth keys
+const Key_Set_Depth = 10
+
+// A set of public keys, whose i-th leaf is Keys[i]
+type Key_Set struct {
+	Keys [][]byte `json:"keys"` // Compressed EdDSA BN254 public keys
+}
+
+// Merkle path of a key set's leaf, from the leaf up to the root
+type Key_Path struct {
+	Index    uint64
+	Siblings [Key_Set_Depth][]byte
+}
+
+// Returns the key set of the given keys
+func NewKeySet(keys ...signature.PublicKey) (Key_Set, error) {
+	set := Key_Set{Keys: [][]byte{}}
+	for _, key := range keys {
+		if err := set.Add(key); err != nil {
+			return Key_Set{}, err
+		}
+	}
+	return set, nil
+}
+
+// Append the key to the set, unless it is already in it.
+// The paths of the other keys change, provers compute them from the current set.
+func (set *Key_Set) Add(key signature.PublicKey) error {
+	if set.Index(key) >= 0 {
+		return nil
+	}
+	if len(set.Keys) >= 1<<Key_Set_Depth {
+		return fmt.Errorf("%w: key set is full (%d keys)", ErrInvalidKeySet, len(set.Keys))
+	}
+	set.Keys = append(set.Keys, key.Bytes())
+	return nil
+}
+
+// Returns the index of the key in the set, or -1 if it is not in the set
+func (set Key_Set) Index(key signature.PublicKey) int {
+	if key == nil {
+		return -1
+	}
+	for i, k := range set.Keys {
+		if bytes.Equal(k, key.Bytes()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the set of its first n keys, as it was before the others were added. Keys are only ever appended,
+// so a proof made against an earlier root stays valid. The whole set is returned if n is 0.
+func (set Key_Set) Prefix(n int) (Key_Set, error) {
+	if n == 0 {
+		return set, nil
+	}
+	if n < 0 || n > len(set.Keys) {
+		return Key_Set{}, fmt.Errorf("%w: no prefix of %d keys in a set of %d", ErrInvalidKeySet, n, len(set.Keys))
+	}
+	return Key_Set{Keys: set.Keys[:n]}, nil
+}
+
+// Returns ErrInvalidKeySet if the set is too large or holds a malformed key
+func (set Key_Set) Validate() error {
+	if len(set.Keys) > 1<<Key_Set_Depth {
+		return fmt.Errorf("%w: %d keys, at most %d", ErrInvalidKeySet, len(set.Keys), 1<<Key_Set_Depth)
+	}
+	for i, key := range set.Keys {
+		if _, err := KeyLeaf(key); err != nil {
+			return fmt.Errorf("%w: key %d: %w", ErrInvalidKeySet, i, err)
+		}
+	}
+	return nil
+}
+
+// Returns the root of the set's Merkle tree
+func (set Key_Set) Root() ([]byte, error) {
+	levels, err := set.tree()
+	if err != nil {
+		return nil, err
+	}
+	return levels[Key_Set_Depth][0], nil
+}
+
+// Returns the Merkle path of the key.
+// Returns ErrNotAuthorised if the key is not in the set.
+func (set Key_Set) Path(key signature.PublicKey) (Key_Path, error) {
+	index := set.Index(key)
+	if index < 0 {
+		return Key_Path{}, ErrNotAuthorised
+	}
+
+	levels, err := set.tree()
+	if err != nil {
+		return Key_Path{}, err
+	}
+	return merklePath(levels, index), nil
+}
+
+// Returns every level of the Merkle tree, from the leaves (level 0) to the root (level Key_Set_Depth)
+func (set Key_Set) tree() ([][][]byte, error) {
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+
+	leaves := make([][]byte, len(set.Keys))
+	for i, key := range set.Keys {
+		leaf, err := KeyLeaf(key)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+	}
+
+	return merkleTree(leaves, make([]byte, 32)), nil // Empty leaves are 0
+}
+
+// Returns every level of the Merkle tree of depth Key_Set_Depth over the leaves, padded with pad
+func merkleTree(leaves [][]byte, pad []byte) [][][]byte {
+	levels := make([][][]byte, Key_Set_Depth+1)
+	levels[0] = make([][]byte, 1<<Key_Set_Depth)
+	for i := range levels[0] {
+		levels[0][i] = pad
+	}
+	copy(levels[0], leaves)
+
+	for level := 1; level <= Key_Set_Depth; level++ {
+		below := levels[level-1]
+		levels[level] = make([][]byte, len(below)/2)
+		for i := range levels[level] {
+			h := out_mimc.NewMiMC()
+			h.Write(below[2*i])
+			h.Write(below[2*i+1])
+			levels[level][i] = h.Sum(nil)
+		}
+	}
+
+	return levels
+}
+
+// Returns the Merkle path of the leaf at index
+func merklePath(levels [][][]byte, index int) Key_Path {
+	path := Key_Path{Index: uint64(index)}
+	for level := 0; level < Key_Set_Depth; level++ {
+		path.Siblings[level] = levels[level][index^1]
+		index >>= 1
+	}
+	return path
+}
+
+// Returns the Merkle leaf of a public key serialised with PublicKey.Bytes()
+func KeyLeaf(key []byte) ([]byte, error) {
+	point := new(eddsa_bn254.PublicKey)
+	if _, err := point.SetBytes(key); err != nil {
+		return nil, Wrap(ErrDecode, err)
+	}
+
+	h := out_mimc.NewMiMC()
+	h.Write(point.A.X.Marshal())
+	h.Write(point.A.Y.Marshal())
+	return h.Sum(nil), nil
+}
+
+/*----------------------------------------------------In-Circuit-------------------------------------------------*/
+
+// [Gnark-friendly] Key_Path
+type Fr_Key_Path struct {
+	Index    frontend.Variable
+	Siblings [Key_Set_Depth]frontend.Variable
+}
+
+// Returns the Fr_Key_Path of the path
+func (path Key_Path) ToFr() Fr_Key_Path {
+	fr_path := Fr_Key_Path{Index: path.Index}
+	for i, sibling := range path.Siblings {
+		fr_path.Siblings[i] = sibling
+	}
+	return fr_path
+}
+
+// Returns the path of index 0 whose siblings are all 0, assigned to unused paths
+func zeroPath() Fr_Key_Path {
+	fr_path := Fr_Key_Path{Index: 0}
+	for i := range fr_path.Siblings {
+		fr_path.Siblings[i] = 0
+	}
+	return fr_path
+}
+
+// [In-Circuit] Returns the root of the key set in which key is the leaf at path
+func Fr_KeySetRoot(api frontend.API, key eddsa.PublicKey, path Fr_Key_Path) frontend.Variable {
+	return Fr_MerkleRoot(api, Fr_KeyLeaf(api, key), path)
+}
+
+// [In-Circuit] Returns the Merkle leaf of a public key, mirroring KeyLeaf()
+func Fr_KeyLeaf(api frontend.API, key eddsa.PublicKey) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+	h.Write(key.A.X, key.A.Y)
+	return h.Sum()
+}
+
+// [In-Circuit] Returns the root of the Merkle tree in which leaf is at path.
+// The index is asserted to fit in Key_Set_Depth bits, so every path has a single index.
+func Fr_MerkleRoot(api frontend.API, leaf frontend.Variable, path Fr_Key_Path) frontend.Variable {
+	h, _ := mimc.NewMiMC(api)
+	node := leaf
+
+	bits := api.ToBinary(path.Index, Key_Set_Depth)
+	for level, sibling := range path.Siblings {
+		// bit == 1: node is the right child
+		left := api.Select(bits[level], sibling, node)
+		right := api.Select(bits[level], node, sibling)
+
+		h.Reset()
+		h.Write(left, right)
+		node = h.Sum()
+	}
+
+	return node
+}
@@ -0,0 +1,54 @@
+package photoproof
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	gnarklogger "github.com/consensys/gnark/logger"
+	"github.com/rs/zerolog"
+)
+
+/*-----------------------------------------------------Logging---------------------------------------------------*/
+
+var logger atomic.Pointer[slog.Logger]
+
+// Used until SetLogger() is called
+var discard = slog.New(slog.DiscardHandler)
+
+// Set the logger used by photoproof and camera. A nil logger discards every record.
+// Until it is called nothing is logged, and gnark's logger is left as the application set it.
+//
+// Gnark's own logs (compilation, solver & prover timings) are forwarded to l at debug level,
+// with the original gnark event in the "gnark" attribute: SetLogger() replaces gnark's global logger.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = discard
+	}
+	logger.Store(l)
+	gnarklogger.Set(zerolog.New(gnarkWriter{l}))
+}
+
+// Returns the logger set by SetLogger(), or one that discards every record
+func Logger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return discard
+}
+
+// Forwards gnark's zerolog events to a slog.Logger
+type gnarkWriter struct {
+	l *slog.Logger
+}
+
+func (w gnarkWriter) Write(p []byte) (int, error) {
+	w.l.Debug("gnark", "gnark", strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,29 @@
+package photoproof
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	gnarklogger "github.com/consensys/gnark/logger"
+)
+
+func TestSetLogger(t *testing.T) {
+	if Logger() == nil {
+		t.Fatal("no logger before SetLogger()")
+	}
+
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer SetLogger(nil)
+
+	Logger().Info("photoproof record")
+	gnark := gnarklogger.Logger()
+	gnark.Info().Msg("gnark record")
+	for _, record := range []string{"photoproof record", "gnark record"} {
+		if !strings.Contains(buf.String(), record) {
+			t.Errorf("%q was not logged: %s", record, buf.String())
+		}
+	}
+}
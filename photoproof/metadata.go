@@ -0,0 +1,29 @@
+package photoproof
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+/*-------------------------------------------------Capture Metadata----------------------------------------------*/
+
+// [Gnark-friendly] Assert that Z_out's metadata is Z_in's. Only "redact_gps", when it is the applied
+// transformation, changes it: the GPS location is stripped, while GPS_Recorded is kept so that verifiers know
+// there was one.
+func Check_Metadata(api frontend.API, circuit *PhotoGnark) {
+	md_in, md_out := circuit.Z_in.Img.Metadata, circuit.Z_out.Img.Metadata
+
+	redacted := frontend.Variable(0)
+	if slot := circuit.Policy.Slot("redact_gps"); slot >= 0 {
+		redacted = circuit.Tr_Flags[slot]
+	}
+	kept := api.Sub(1, redacted)
+
+	api.AssertIsEqual(md_out.Device_Id, md_in.Device_Id)
+	api.AssertIsEqual(md_out.GPS_Recorded, md_in.GPS_Recorded)
+	api.AssertIsEqual(md_out.Exposure, md_in.Exposure)
+	api.AssertIsEqual(md_out.Focal_Length, md_in.Focal_Length)
+
+	api.AssertIsEqual(md_out.GPS_Present, api.Mul(kept, md_in.GPS_Present))
+	api.AssertIsEqual(md_out.Latitude, api.Mul(kept, md_in.Latitude))
+	api.AssertIsEqual(md_out.Longitude, api.Mul(kept, md_in.Longitude))
+}
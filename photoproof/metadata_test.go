@@ -0,0 +1,124 @@
+package photoproof
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+// Returns a camera's original photograph with capture metadata, and an editor
+func testMetadataOriginal(t *testing.T) (Photograph, User) {
+	t.Helper()
+	img := testImage(t, "random")
+	img.Metadata = image.Metadata{
+		Device_Serial: "PG-0001",
+		GPS:           &image.GPS{Latitude: 40_443_322, Longitude: -79_943_041},
+		Exposure:      8_000,
+		Focal_Length:  4_250,
+	}
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), img)
+
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return photo, editor
+}
+
+func TestCheck_Metadata(t *testing.T) {
+	photo, editor := testMetadataOriginal(t)
+
+	// Edits carry the metadata over
+	edited, circuit := testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	if edited.Z.Img.Metadata.GPS == nil {
+		t.Fatal("identity stripped the GPS location")
+	}
+	if err := testSolve(t, photo.ProvingKeys, circuit, edited.Z.Img, editor.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// ... whatever the editor claims
+	for name, change := range map[string]func(*image.Metadata){
+		"GPS location":  func(md *image.Metadata) { md.GPS = nil },
+		"moved GPS":     func(md *image.Metadata) { md.GPS = &image.GPS{Latitude: 0, Longitude: 0} },
+		"exposure":      func(md *image.Metadata) { md.Exposure++ },
+		"focal length":  func(md *image.Metadata) { md.Focal_Length++ },
+		"device serial": func(md *image.Metadata) { md.Device_Serial = "PG-0002" },
+		"GPS recorded":  func(md *image.Metadata) { md.GPS, md.GPS_Recorded = nil, false },
+	} {
+		out := edited.Z.Img
+		change(&out.Metadata)
+		_, circuit := testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+		testResign(t, editor, photo.ProvingKeys, circuit, out)
+		if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+			t.Errorf("an identity edit changing the %s was accepted", name)
+		}
+	}
+}
+
+func TestRedact_GPS(t *testing.T) {
+	photo, editor := testMetadataOriginal(t)
+
+	redacted, circuit := testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+	if redacted.Z.Img.Metadata.GPS != nil || !redacted.Z.Img.Metadata.GPS_Recorded {
+		t.Fatalf("redacted metadata %+v, expected a recorded location without GPS", redacted.Z.Img.Metadata)
+	}
+	if err := testSolve(t, photo.ProvingKeys, circuit, redacted.Z.Img, editor.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// A redaction must strip the location, and only the location
+	for name, change := range map[string]func(*image.Metadata){
+		"keeping the GPS location":   func(md *image.Metadata) { md.GPS = photo.Z.Img.Metadata.GPS },
+		"forgetting it was recorded": func(md *image.Metadata) { md.GPS_Recorded = false },
+		"changing the exposure":      func(md *image.Metadata) { md.Exposure++ },
+	} {
+		out := redacted.Z.Img
+		change(&out.Metadata)
+		_, circuit := testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+		testResign(t, editor, photo.ProvingKeys, circuit, out)
+		if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+			t.Errorf("a redaction %s was accepted", name)
+		}
+	}
+
+	// ... and keep every pixel
+	out := redacted.Z.Img
+	out.Pxls[0].RGB[0] ^= 0xff
+	out.PxlBytes = image.BigInt_to_Fr_Bytes(out)
+	_, circuit = testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Error("a redaction changing a pixel was accepted")
+	}
+}
+
+// A redaction of an image without a recorded location is refused, rather than spending its budget for nothing
+func TestRedact_GPS_NoLocation(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "random"))
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, _ := Lookup("redact_gps")
+	if err := reg.Applies(photo.Z.Img, Redact_GPS_Tr_Params{}); !errors.Is(err, ErrNotPermitted) {
+		t.Fatalf("redact_gps of an image without GPS returned %v, expected %v", err, ErrNotPermitted)
+	}
+
+	out, err := photo.ProvingKeys.Policy.Consume(Redact_GPS_Tr{}.Apply(photo.Z.Img, nil), "redact_gps", Redact_GPS_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags, params, err := assignTransformation(photo.ProvingKeys.Policy, "redact_gps", Redact_GPS_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, circuit := testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	circuit.Tr_Flags, circuit.Tr_Params = flags, params
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("a redaction of an image without a recorded location was accepted")
+	}
+}
@@ -13,17 +13,55 @@ type PhotoGnark struct {
 	Signature_out eddsa.Signature   `gnark:",secret"`
 	Originality   frontend.Variable `gnark:",secret"`
 
-	// TODO add all transformation fields and define them & their params
-	// You may add all existing transformation,
-	// but their bounds will be controlled by the image's Provenance bounds.
-	// For example, blocking cropping can be done with a bound of 0% of the image size .
-	Parameters Fr_Parameters `gnark:",secret"`
+	// Z_in is the camera's original image (Input_Original == 1), or the output of the previous edit, whose proof
+	// has Commitment_in as the commitment to its Z_out (see image.ImageCommitment and Proof.Previous)
+	Commitment_in  frontend.Variable `gnark:",public"`
+	Input_Original frontend.Variable `gnark:",public"`
+
+	// The original public key is in the Admin's set of authorised cameras (see Key_Set)
+	Cameras_Root frontend.Variable `gnark:",public"`
+	Camera_Path  Fr_Key_Path       `gnark:",secret"`
+
+	// The original public key is not in the Admin's revocation list (see Revocation_List)
+	Revocation_Root  frontend.Variable `gnark:",public"`
+	Revocation_Proof Fr_Non_Membership `gnark:",secret"`
 
 	/*List of permissible transformations*/
-	Identity Fr_Identity_Tr `gnark:",secret"`
+	// You may add all existing transformation by registering them (see registry.go),
+	// then enable them in the Admin's Policy, with bounds controlled by the image's Provenance bounds.
+	// For example, blocking cropping can be done with a bound of 0% of the image size .
+	Tr_Flags  []frontend.Variable `gnark:",secret"` // One flag per enabled transformation, 1 for the applied one
+	Tr_Params []frontend.Variable `gnark:",secret"` // Parameters of the applied transformation, see Registration.Assign
+
+	// The Admin's policy, compiled into the circuit. It is not part of the witness.
+	Policy Policy `gnark:"-"`
+
+	// Hash function of image hashes and signatures, see Mode.Hash(). It is not part of the witness.
+	Hash image.Hash `gnark:"-"`
+}
+
+// Returns an empty PhotoGnark circuit for the given policy, sized for its enabled transformations
+func NewPhotoGnark(policy Policy) *PhotoGnark {
+	return &PhotoGnark{
+		Tr_Flags:  make([]frontend.Variable, len(policy.Transformations)),
+		Tr_Params: make([]frontend.Variable, policy.Nb_Params()),
+		Policy:    policy,
+	}
 }
 
 func (circuit *PhotoGnark) Define(api frontend.API) error {
+	if err := circuit.Policy.Validate(); err != nil {
+		return err
+	}
+
+	// In both cases, Z_in was signed by an authorised camera, whose key Z_out carries on
+	api.AssertIsEqual(Fr_KeySetRoot(api, circuit.Z_in.Original_PublicKey, circuit.Camera_Path), circuit.Cameras_Root)
+
+	// ... which has not been revoked
+	api.AssertIsEqual(Fr_RevocationRoot(api, circuit.Z_in.Original_PublicKey, circuit.Revocation_Proof), circuit.Revocation_Root)
+
+	// Z_in is the original image or the output of a proven edit
+	Check_Input(api, circuit)
 
 	ok := api.Select(
 		circuit.Originality,                     // 1 if this is an original image
@@ -39,16 +77,39 @@ func (circuit *PhotoGnark) Define(api frontend.API) error {
 
 /*-------------------------------------------Functions used in Define()------------------------------------------*/
 
-// Case 1: Z_in is an original image. Verify Z_in's original hash, signature and public key.
-// Only requires Z_in, no need for Z_out.
-func Verify_Original_Signature(api frontend.API, circuit *PhotoGnark) frontend.Variable {
+// Z_in is bound to the public Commitment_in, and to the signed original when it is the original image.
+// Proofs are not recursive: the verifier checks the previous edit's proof, whose Z_out commits to Commitment_in,
+// so every edit of a chain is made from the output of the one before and budgets are consumed cumulatively.
+func Check_Input(api frontend.API, circuit *PhotoGnark) {
+	api.AssertIsBoolean(circuit.Input_Original)
+
+	// An original image is its own input
+	api.AssertIsEqual(api.Mul(circuit.Originality, api.Sub(1, circuit.Input_Original)), 0)
+
+	// The pixels that transformations compare are the ones that were committed to
+	image.Fr_AssertPxlBytes(api, circuit.Z_in.Img)
+	api.AssertIsEqual(circuit.Commitment_in, image.Fr_ImageCommitment(api, circuit.Z_in.Img))
+
+	// Section V-F: the original hash matches the original image and its capture time & counter
+	digest := image.Fr_CaptureHash(api, circuit.Z_in.Img, circuit.Z_in.Capture) // Calculate hash in secret
+	api.AssertIsEqual(api.Mul(circuit.Input_Original, api.Sub(circuit.Z_in.Original_Hash, digest)), 0)
+
+	// The camera initialised the provenance from the Admin's policy: one entry per enabled transformation.
+	// An edit only applies the transformations enabled at capture, within what is left of their bounds.
+	provenance := circuit.Policy.Provenance()
+	for i := range provenance {
+		api.AssertIsEqual(api.Mul(circuit.Input_Original, api.Sub(circuit.Z_in.Img.Provenance[i].Tr_Name, provenance[i].Tr_Name)), 0)
+		api.AssertIsEqual(api.Mul(circuit.Input_Original, api.Sub(circuit.Z_in.Img.Provenance[i].Tr_Bound, provenance[i].Tr_Bound)), 0)
+	}
+}
 
-	// Section V-F: the original hash matches the image
-	digest := image.Fr_ImageHash(api, circuit.Z_in.Img) // Calculate hash in secret
-	api.AssertIsEqual(circuit.Z_in.Original_Hash, digest)
+// Z_in is an original image. Verify Z_in's original signature and public key.
+// Only requires Z_in, no need for Z_out. It holds for edits too (Case 2), whose Z_in carries the original's.
+func Verify_Original_Signature(api frontend.API, circuit *PhotoGnark) frontend.Variable {
+	api.AssertIsBoolean(circuit.Originality)
 
-	// verify the original hash against the original signature, using the Admin's public key
-	Verify_Signature(api, circuit.Z_in.Original_Hash, circuit.Z_in.Original_Signature, circuit.Z_in.Original_PublicKey)
+	// verify the original hash against the original signature, using the camera's public key
+	Verify_Signature(api, circuit.Hash, circuit.Z_in.Original_Hash, circuit.Z_in.Original_Signature, circuit.Z_in.Original_PublicKey)
 
 	return 1
 }
@@ -77,41 +138,43 @@ func Check_Transformation(api frontend.API, circuit *PhotoGnark) frontend.Variab
 	// Requirement: Assert Z_in (secret) and Z_out (public) have equal Original hash
 	api.AssertIsEqual(circuit.Z_in.Original_Hash, circuit.Z_out.Original_Hash)
 
+	// Requirement: the capture time & counter are passed down unchanged, like the original hash they are hashed into
+	api.AssertIsEqual(circuit.Z_in.Capture.Time, circuit.Z_out.Capture.Time)
+	api.AssertIsEqual(circuit.Z_in.Capture.Counter, circuit.Z_out.Capture.Counter)
+
 	// Verify the output signature is valid. This is useful for the verifier to recognize that
 	// the prover's Z_out image is the same as the known Z_out, and signature can be kept secret.
-	digest := image.Fr_ImageHash(api, circuit.Z_out.Img)
-	Verify_Signature(api, digest, circuit.Signature_out, circuit.PublicKey_out)
+	digest := circuit.Hash.Fr_ImageHash(api, circuit.Z_out.Img)
+	Verify_Signature(api, circuit.Hash, digest, circuit.Signature_out, circuit.PublicKey_out)
 
-	// TODO: ensure that the  permissible transformations are applied
+	// Requirement: Z_out's provenance budgets are Z_in's, minus what the applied transformation consumed
+	Check_Provenance(api, circuit)
 
-	/* Identity Transformation checks that the images are equal to the signature. */
-	result := api.Select(
-		circuit.Identity.Flag,
-		circuit.Identity.Apply(api, circuit),
-		frontend.Variable(0),
-	)
+	// Requirement: Z_out's metadata is Z_in's, unless the applied transformation redacts it
+	Check_Metadata(api, circuit)
 
-	// Example of how to apply a new transformation
 	/*
-		result = api.Select(
-					result, // If other transformation has already occured, do not apply any other transformation
-					1,
-					api.Select(
-						circuit.tr.Flag,
-						circuit.tr.Apply(api, circuit),
-						0),
-					)
-	*/
+		Apply every enabled transformation's gadget and keep the result of the flagged one.
 
-	/*
-		This ensures that at least one transformation is required to create a proof,
-		including proof of originality.
+		Exactly one flag must be set, including for proofs of originality (which use "identity"),
+		so at least one transformation is required to create a proof.
 
-		result == 1, then at least one transformation's flag is 1 and applying tr is successful
-		result == 0, no transformation was applied
+		result == 1, then the flagged transformation's gadget was successful
+		result == 0, the flagged transformation does not relate Z_in to Z_out
 
-		*NOTE: tr.Apply must always return 1 and assert some relationship between Z_in and Z_out.
+		*NOTE: a Gadget returns 0 or 1 instead of asserting, since every gadget is part of the circuit.
 	*/
+	result := frontend.Variable(0)
+	nb_flags := frontend.Variable(0)
+	for i, reg := range circuit.Policy.Registrations() {
+		flag := circuit.Tr_Flags[i]
+		api.AssertIsBoolean(flag)
+
+		nb_flags = api.Add(nb_flags, flag)
+		result = api.Add(result, api.Mul(flag, reg.Gadget(api, circuit, circuit.Tr_Params)))
+	}
+
+	api.AssertIsEqual(1, nb_flags)
 	api.AssertIsEqual(1, result)
 
 	return 1
@@ -0,0 +1,251 @@
+package photoproof
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+// Selects which PhotoGnark circuit the Admin's keys were generated for. Options are combined with |.
+type Mode uint8
+
+const (
+	// Every pixel of Z_out is a public input (PhotoGnark)
+	Mode_Public Mode = 0
+	// Only a commitment to Z_out's image is a public input (PhotoGnark_Private)
+	Mode_Private Mode = 1 << 0
+	// With Mode_Private: the original public key is not a public input, only the root of the authorised cameras
+	// is, so verifiers do not learn which camera took the photograph
+	Mode_Hidden_Camera Mode = 1 << 1
+	// With Mode_Private: the editor's public key is not a public input, only the root of the authorised editors
+	// is, so verifiers do not learn which editor made the last edit. The editor still signs Z_out.
+	Mode_Hidden_Editor Mode = 1 << 2
+	// Region proofs (PhotoGnark_Region) instead of edits: the pixels of Z_out within a public area are those of the
+	// original signed image, the rest is unconstrained. Can only be combined with Mode_Poseidon2.
+	Mode_Region Mode = 1 << 3
+	// Image hashes and the hash of EdDSA signatures use Poseidon2 instead of MiMC, in and out of the circuit (see
+	// image.Hash). Nothing else changes: the capture hash, image and tile commitments, the Merkle trees of cameras,
+	// editors and revocations, timestamp tokens and custody logs use MiMC in every mode.
+	Mode_Poseidon2 Mode = 1 << 4
+)
+
+// Returns true if Z_out is kept secret (PhotoGnark_Private)
+func (mode Mode) Private() bool {
+	return mode&Mode_Private != 0
+}
+
+// Returns true if the camera's public key is kept secret
+func (mode Mode) Hidden_Camera() bool {
+	return mode&Mode_Hidden_Camera != 0
+}
+
+// Returns true if the editor's public key is kept secret
+func (mode Mode) Hidden_Editor() bool {
+	return mode&Mode_Hidden_Editor != 0
+}
+
+// Returns true if the keys prove regions of original images instead of edits (PhotoGnark_Region)
+func (mode Mode) Region() bool {
+	return mode&Mode_Region != 0
+}
+
+// Returns the hash function of image hashes and signatures, the only ones that depend on the mode
+func (mode Mode) Hash() image.Hash {
+	if mode&Mode_Poseidon2 != 0 {
+		return image.Hash_Poseidon2
+	}
+	return image.Hash_MiMC
+}
+
+// Returns ErrInvalidMode if the mode combines options that do not go together
+func (mode Mode) Validate() error {
+	if mode&^(Mode_Private|Mode_Hidden_Camera|Mode_Hidden_Editor|Mode_Region|Mode_Poseidon2) != 0 {
+		return fmt.Errorf("%w: unknown options %b", ErrInvalidMode, mode)
+	}
+	if mode.Hidden_Camera() && !mode.Private() {
+		return fmt.Errorf("%w: hiding the camera requires Mode_Private, PhotoGnark's public Z_out holds its key", ErrInvalidMode)
+	}
+	if mode.Hidden_Editor() && !mode.Private() {
+		return fmt.Errorf("%w: hiding the editor requires Mode_Private, PhotoGnark's public inputs hold its key", ErrInvalidMode)
+	}
+	if mode.Region() && mode&^Mode_Poseidon2 != Mode_Region {
+		return fmt.Errorf("%w: Mode_Region can only be combined with Mode_Poseidon2", ErrInvalidMode)
+	}
+	return nil
+}
+
+// Returns an empty circuit of the given mode and policy, to be compiled by the Admin
+func NewCircuit(mode Mode, policy Policy) frontend.Circuit {
+	if mode.Region() {
+		return &PhotoGnark_Region{Hash: mode.Hash()}
+	}
+	circuit := NewPhotoGnark(policy)
+	circuit.Hash = mode.Hash()
+	if mode.Private() {
+		return &PhotoGnark_Private{Tr_Flags: circuit.Tr_Flags, Tr_Params: circuit.Tr_Params, Policy: policy, Mode: mode}
+	}
+	return circuit
+}
+
+/*
+PhotoGnark marks the whole Z_out as public, so every pixel becomes a public input and verification cost
+grows with the image size. PhotoGnark_Private keeps Z_out secret and only exposes:
+  - a commitment to Z_out's image (see image.ImageCommitment),
+  - a commitment to Z_in's image, and whether it is the original image, as in PhotoGnark,
+  - the original public key, always 0 with Mode_Hidden_Camera,
+  - the original hash and capture time & counter,
+  - the editor's public key, always 0 with Mode_Hidden_Editor,
+  - the root of the authorised editors, always 0 without Mode_Hidden_Editor,
+  - the root of the authorised cameras,
+  - the root of the revoked cameras.
+
+The verifier recomputes the commitment from the delivered image out-of-circuit, so verification
+is constant-size no matter how large the image is.
+*/
+type PhotoGnark_Private struct {
+	Z_in               image.Fr_Z        `gnark:",secret"`
+	Z_out              image.Fr_Z        `gnark:",secret"`
+	Commitment_out     frontend.Variable `gnark:",public"`
+	Commitment_in      frontend.Variable `gnark:",public"`
+	Input_Original     frontend.Variable `gnark:",public"`
+	Original_PublicKey eddsa.PublicKey   `gnark:",public"`
+	Original_Hash      frontend.Variable `gnark:",public"`
+	Capture            image.Fr_Capture  `gnark:",public"`
+	PublicKey_out      eddsa.PublicKey   `gnark:",public"`
+	Editor_PublicKey   eddsa.PublicKey   `gnark:",secret"` // The key that signed Z_out, PublicKey_out unless it is hidden
+	Editors_Root       frontend.Variable `gnark:",public"`
+	Editor_Path        Fr_Key_Path       `gnark:",secret"`
+	Signature_out      eddsa.Signature   `gnark:",secret"`
+	Originality        frontend.Variable `gnark:",secret"`
+	Cameras_Root       frontend.Variable `gnark:",public"`
+	Camera_Path        Fr_Key_Path       `gnark:",secret"`
+	Revocation_Root    frontend.Variable `gnark:",public"`
+	Revocation_Proof   Fr_Non_Membership `gnark:",secret"`
+
+	/*List of permissible transformations*/
+	Tr_Flags  []frontend.Variable `gnark:",secret"`
+	Tr_Params []frontend.Variable `gnark:",secret"`
+
+	Policy Policy `gnark:"-"`
+	Mode   Mode   `gnark:"-"`
+}
+
+func (circuit *PhotoGnark_Private) Define(api frontend.API) error {
+
+	// Bind the secret pixels to the PxlBytes that are hashed & signed, then to the public commitment
+	image.Fr_AssertPxlBytes(api, circuit.Z_out.Img)
+	api.AssertIsEqual(circuit.Commitment_out, image.Fr_ImageCommitment(api, circuit.Z_out.Img))
+
+	// The public original key is the one carried by Z_out, unless the camera is hidden.
+	// A hidden camera's key is only known to be in the Cameras_Root, the public key is fixed to 0.
+	if circuit.Mode.Hidden_Camera() {
+		api.AssertIsEqual(circuit.Original_PublicKey.A.X, 0)
+		api.AssertIsEqual(circuit.Original_PublicKey.A.Y, 0)
+	} else {
+		api.AssertIsEqual(circuit.Original_PublicKey.A.X, circuit.Z_out.Original_PublicKey.A.X)
+		api.AssertIsEqual(circuit.Original_PublicKey.A.Y, circuit.Z_out.Original_PublicKey.A.Y)
+	}
+
+	// The public original hash and capture are Z_out's
+	api.AssertIsEqual(circuit.Original_Hash, circuit.Z_out.Original_Hash)
+	api.AssertIsEqual(circuit.Capture.Time, circuit.Z_out.Capture.Time)
+	api.AssertIsEqual(circuit.Capture.Counter, circuit.Z_out.Capture.Counter)
+
+	// The public editor's key is the one that signed Z_out, unless the editor is hidden.
+	// A hidden editor's key is only known to be in the Editors_Root, the public key is fixed to 0.
+	if circuit.Mode.Hidden_Editor() {
+		api.AssertIsEqual(circuit.PublicKey_out.A.X, 0)
+		api.AssertIsEqual(circuit.PublicKey_out.A.Y, 0)
+		Check_Editor(api, circuit)
+	} else {
+		api.AssertIsEqual(circuit.PublicKey_out.A.X, circuit.Editor_PublicKey.A.X)
+		api.AssertIsEqual(circuit.PublicKey_out.A.Y, circuit.Editor_PublicKey.A.Y)
+
+		// Unused without Mode_Hidden_Editor, fixed to 0
+		api.AssertIsEqual(circuit.Editors_Root, 0)
+		api.AssertIsEqual(circuit.Editor_Path.Index, 0)
+		for _, sibling := range circuit.Editor_Path.Siblings {
+			api.AssertIsEqual(sibling, 0)
+		}
+	}
+
+	// Everything else is the same compliance predicate as PhotoGnark
+	return circuit.PhotoGnark().Define(api)
+}
+
+// Mode_Hidden_Editor: the editor that signed Z_out is one of the Admin's authorised editors.
+// An original image is signed by its camera instead, which must be the one that took it.
+func Check_Editor(api frontend.API, circuit *PhotoGnark_Private) {
+	editor := circuit.Editor_PublicKey
+	edited := api.Sub(1, circuit.Originality)
+
+	root := Fr_KeySetRoot(api, editor, circuit.Editor_Path)
+	api.AssertIsEqual(api.Mul(edited, api.Sub(root, circuit.Editors_Root)), 0)
+
+	api.AssertIsEqual(api.Mul(circuit.Originality, api.Sub(editor.A.X, circuit.Z_in.Original_PublicKey.A.X)), 0)
+	api.AssertIsEqual(api.Mul(circuit.Originality, api.Sub(editor.A.Y, circuit.Z_in.Original_PublicKey.A.Y)), 0)
+}
+
+// Returns the PhotoGnark view of this circuit, sharing the same variables
+func (circuit *PhotoGnark_Private) PhotoGnark() *PhotoGnark {
+	return &PhotoGnark{
+		Z_in:             circuit.Z_in,
+		Z_out:            circuit.Z_out,
+		PublicKey_out:    circuit.Editor_PublicKey,
+		Signature_out:    circuit.Signature_out,
+		Originality:      circuit.Originality,
+		Commitment_in:    circuit.Commitment_in,
+		Input_Original:   circuit.Input_Original,
+		Cameras_Root:     circuit.Cameras_Root,
+		Camera_Path:      circuit.Camera_Path,
+		Revocation_Root:  circuit.Revocation_Root,
+		Revocation_Proof: circuit.Revocation_Proof,
+		Tr_Flags:         circuit.Tr_Flags,
+		Tr_Params:        circuit.Tr_Params,
+		Policy:           circuit.Policy,
+		Hash:             circuit.Mode.Hash(),
+	}
+}
+
+// Returns the PhotoGnark_Private assignment of the given mode for a PhotoGnark assignment,
+// where commitment_out is the commitment to Z_out's image. With Mode_Hidden_Editor, editors_root and editor_path prove that
+// the editor is authorised, both are 0 otherwise.
+func (circuit *PhotoGnark) Private(commitment_out []byte, mode Mode, editors_root frontend.Variable, editor_path Fr_Key_Path) *PhotoGnark_Private {
+	original_pk := circuit.Z_out.Original_PublicKey
+	if mode.Hidden_Camera() {
+		original_pk = eddsa.PublicKey{}
+		original_pk.A.X, original_pk.A.Y = 0, 0
+	}
+	pk_out := circuit.PublicKey_out
+	if mode.Hidden_Editor() {
+		pk_out = eddsa.PublicKey{}
+		pk_out.A.X, pk_out.A.Y = 0, 0
+	}
+
+	return &PhotoGnark_Private{
+		Z_in:               circuit.Z_in,
+		Z_out:              circuit.Z_out,
+		Commitment_out:     commitment_out,
+		Commitment_in:      circuit.Commitment_in,
+		Input_Original:     circuit.Input_Original,
+		Original_PublicKey: original_pk,
+		Original_Hash:      circuit.Z_out.Original_Hash,
+		Capture:            circuit.Z_out.Capture,
+		PublicKey_out:      pk_out,
+		Editor_PublicKey:   circuit.PublicKey_out,
+		Editors_Root:       editors_root,
+		Editor_Path:        editor_path,
+		Signature_out:      circuit.Signature_out,
+		Originality:        circuit.Originality,
+		Cameras_Root:       circuit.Cameras_Root,
+		Camera_Path:        circuit.Camera_Path,
+		Revocation_Root:    circuit.Revocation_Root,
+		Revocation_Proof:   circuit.Revocation_Proof,
+		Tr_Flags:           circuit.Tr_Flags,
+		Tr_Params:          circuit.Tr_Params,
+		Policy:             circuit.Policy,
+		Mode:               mode,
+	}
+}
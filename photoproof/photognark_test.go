@@ -0,0 +1,440 @@
+package photoproof
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*------------------------------------------------Test Helpers---------------------------------------------------*/
+
+// Circuits compiled by the tests, by mode & policy, since compiling is the slowest part of a test
+var test_circuits sync.Map
+
+// Returns the compiled circuit of the mode & policy
+func testCompile(t *testing.T, mode Mode, policy Policy) constraint.ConstraintSystem {
+	t.Helper()
+	key := fmt.Sprint(mode, policy)
+	if ccs, ok := test_circuits.Load(key); ok {
+		return ccs.(constraint.ConstraintSystem)
+	}
+
+	ccs, err := Compile(mode, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test_circuits.Store(key, ccs)
+	return ccs
+}
+
+// PCD keys generated by the tests, by mode & policy
+var test_keys sync.Map
+
+// Returns photo with PCD keys generated for its mode & policy, proven original
+func testProven(t *testing.T, photo Photograph) Photograph {
+	t.Helper()
+	keys := photo.ProvingKeys
+	ccs := testCompile(t, keys.Mode, keys.Policy)
+
+	key := fmt.Sprint(keys.Mode, keys.Policy)
+	pcd_keys, ok := test_keys.Load(key)
+	if !ok {
+		pk, vk, err := groth16.Setup(ccs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pcd_keys, _ = test_keys.LoadOrStore(key, [2]any{pk, vk})
+	}
+
+	photo.ProvingKeys.ProvingKey = pcd_keys.([2]any)[0].(groth16.ProvingKey)
+	photo.ProvingKeys.Compliance_Predicate = ccs
+	photo.ProvingKeys.Editors = Key_Set{Keys: [][]byte{}}
+	photo.VerifyingKeys = VerifierKeys{
+		VerifyingKey:       pcd_keys.([2]any)[1].(groth16.VerifyingKey),
+		Original_PublicKey: keys.Original_PublicKey,
+		Mode:               keys.Mode,
+		Policy:             keys.Policy,
+		Cameras:            keys.Cameras,
+		Revocations:        keys.Revocations,
+		Editors:            photo.ProvingKeys.Editors,
+	}
+
+	var signature_out eddsa.Signature
+	signature_out.Assign(1, photo.Proof.Signature)
+	proof, err := ProveOriginal(context.Background(), photo, signature_out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	photo.Proof.PCD_Proof = proof
+	photo.ProvingKeys.Record(&photo.Proof)
+	return photo
+}
+
+// Returns a new image of the given flag ("white", "black" or "random")
+func testImage(t *testing.T, flag string) image.Image {
+	t.Helper()
+	img, err := image.NewImage(flag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// Returns a camera and its original photograph of img, signed as camera.NewPhotograph() does. Its prover keys are
+// of the given mode & policy, and authorise the camera, but hold no proving key: tests only solve the circuit.
+func testOriginal(t *testing.T, mode Mode, policy Policy, img image.Image) (User, Photograph) {
+	t.Helper()
+	cam, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img.SetProvenance(policy.Provenance())
+	img.Metadata.GPS_Recorded = img.Metadata.GPS != nil
+
+	hash := mode.Hash()
+	capture := image.Capture{Time: 1_700_000_000, Counter: 1}
+	original_hash := image.CaptureHash(img, capture)
+	original_signature, err := cam.SignDigest(hash, original_hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature_out, err := cam.Sign(hash, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cameras, err := NewKeySet(cam.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cam, Photograph{
+		Z: image.Z{
+			Img:                img,
+			Original_PublicKey: cam.PublicKey,
+			Original_Signature: original_signature,
+			Original_Hash:      original_hash,
+			Capture:            capture,
+		},
+		Proof: Proof{Signature: signature_out, PublicKey: cam.PublicKey},
+		ProvingKeys: ProverKeys{
+			Original_PublicKey: cam.PublicKey,
+			Mode:               mode,
+			Policy:             policy,
+			Cameras:            cameras,
+		},
+	}
+}
+
+// Returns the PhotoGnark assignment proving the originality of photo
+func testAssignOriginal(t *testing.T, photo Photograph) *PhotoGnark {
+	t.Helper()
+	var signature_out eddsa.Signature
+	signature_out.Assign(1, photo.Proof.Signature)
+
+	circuit, err := assignOriginal(photo, signature_out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return circuit
+}
+
+// Returns photo_in edited by the editor with the named transformation as User.Edit() does, without proving it,
+// and the PhotoGnark assignment proving the edit
+func testEdit(t *testing.T, editor User, photo_in Photograph, name string, params Parameters) (Photograph, *PhotoGnark) {
+	t.Helper()
+	reg, ok := Lookup(name)
+	if !ok {
+		t.Fatalf("unknown transformation %q", name)
+	}
+
+	img_out, err := photo_in.ProvingKeys.Policy.Consume(reg.Transformation.Apply(photo_in.Z.Img, &params), name, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	photo_out := photo_in
+	photo_out.Z.Img = img_out
+	photo_out.Proof = Proof{PublicKey: editor.PublicKey, Input_Commitment: image.ImageCommitment(photo_in.Z.Img), Previous: photo_in.Edits()}
+	photo_out.Proof.Signature, err = editor.Sign(photo_in.ProvingKeys.Mode.Hash(), img_out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	circuit, err := editor.assignEdit(photo_in, photo_out, reg.Transformation, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return photo_out, circuit
+}
+
+// Sets the assignment's Z_out image to img, signed by the editor
+func testResign(t *testing.T, editor User, keys ProverKeys, circuit *PhotoGnark, img image.Image) {
+	t.Helper()
+	sig, err := editor.Sign(keys.Mode.Hash(), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit.Z_out.Img = image.ImageToFr(img)
+	circuit.Signature_out.Assign(1, sig)
+}
+
+// Returns nil if the assignment satisfies the circuit of the keys' mode & policy, where img_out is Z_out's image
+// and editor the key that signed it, nil for an original image
+func testSolve(t *testing.T, keys ProverKeys, circuit *PhotoGnark, img_out image.Image, editor signature.PublicKey) error {
+	t.Helper()
+	assignment, err := assignMode(keys, circuit, img_out, editor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCompile(t, keys.Mode, keys.Policy).IsSolved(witness)
+}
+
+/*---------------------------------------------------Tests-------------------------------------------------------*/
+
+func TestPhotoGnark_Original(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "random"))
+
+	circuit := testAssignOriginal(t, photo)
+	if err := testSolve(t, photo.ProvingKeys, circuit, photo.Z.Img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// An original image must hash to its original hash
+	circuit = testAssignOriginal(t, photo)
+	circuit.Z_in.Img = image.ImageToFr(testImage(t, "black"))
+	if err := testSolve(t, photo.ProvingKeys, circuit, photo.Z.Img, nil); err == nil {
+		t.Fatal("an image that is not the signed original was proven original")
+	}
+}
+
+func TestPhotoGnark_Edit(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "white"))
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, circuit := testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	if err := testSolve(t, photo.ProvingKeys, circuit, edited.Z.Img, editor.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// An edit's Z_in is bound to the original hash, so a genuine original signature does not vouch for other pixels
+func TestPhotoGnark_UnboundInput(t *testing.T) {
+	white := testImage(t, "white")
+	white.Metadata.GPS = &image.GPS{Latitude: 40_443_322, Longitude: -79_943_041}
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), white)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An all-black image claimed to be the identity of the white original, under the white original's signature
+	black := testImage(t, "black")
+	black.Metadata = photo.Z.Img.Metadata
+	black.SetProvenance(photo.Z.Img.Provenance)
+	_, circuit := testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	circuit.Z_in.Img = image.ImageToFr(black)
+	circuit.Commitment_in = image.ImageCommitment(black)
+	testResign(t, editor, photo.ProvingKeys, circuit, black)
+
+	if err := testSolve(t, photo.ProvingKeys, circuit, black, editor.PublicKey); err == nil {
+		t.Fatal("an edit of an image that is not the signed original was accepted")
+	}
+
+	// Z_in's pixels must be the ones that were hashed, since transformations compare them
+	_, circuit = testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+	out := Redact_GPS_Tr{}.Apply(photo.Z.Img, nil)
+	out.Pxls = black.Pxls
+	out, err = photo.ProvingKeys.Policy.Consume(out, "redact_gps", Redact_GPS_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.PxlBytes = image.BigInt_to_Fr_Bytes(out)
+	circuit.Z_in.Img.Pxls = image.ImageToFr(out).Pxls
+	circuit.Commitment_in = image.ImageCommitment(out)
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("an edit of pixels that were not hashed was accepted")
+	}
+}
+
+// An edit of an edit is proven from the previous edit's output, which its public Commitment_in binds it to
+func TestPhotoGnark_EditOfEdit(t *testing.T) {
+	img := testImage(t, "random")
+	img.Metadata.GPS = &image.GPS{Latitude: 40_443_322, Longitude: -79_943_041}
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), img)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, _ := testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+	twice, circuit := testEdit(t, editor, edited, "identity", Identity_Tr_Params{})
+	if err := testSolve(t, photo.ProvingKeys, circuit, twice.Z.Img, editor.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if len(twice.Proof.Previous) != 1 || !bytes.Equal(twice.Proof.Previous[0].Commitment, twice.Proof.Input_Commitment) {
+		t.Fatal("an edit of an edit does not carry the previous edit's proof")
+	}
+
+	// Z_in must be the image the previous edit output, not other pixels
+	other := edited.Z.Img
+	other.Pxls[0].RGB[0] ^= 0xff
+	other.PxlBytes = image.BigInt_to_Fr_Bytes(other)
+	circuit.Z_in.Img = image.ImageToFr(other)
+	circuit.Z_out.Img = image.ImageToFr(other)
+	testResign(t, editor, photo.ProvingKeys, circuit, other)
+	if err := testSolve(t, photo.ProvingKeys, circuit, other, editor.PublicKey); err == nil {
+		t.Fatal("an edit of an image that is not the previous edit's output was accepted")
+	}
+
+	// ... nor can an edited image be claimed to be the original
+	_, circuit = testEdit(t, editor, edited, "identity", Identity_Tr_Params{})
+	circuit.Input_Original = 1
+	if err := testSolve(t, photo.ProvingKeys, circuit, twice.Z.Img, editor.PublicKey); err == nil {
+		t.Fatal("an edited image was accepted as the original")
+	}
+}
+
+// An edit only applies the transformations enabled at capture, by the provenance the camera signed
+func TestPhotoGnark_CapturePolicy(t *testing.T) {
+	forensic := Policy{Name: "forensic", Transformations: []Policy_Entry{{Name: "identity", Bound: 1}}}
+	_, photo := testOriginal(t, Mode_Public, forensic, testImage(t, "random"))
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, circuit := testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	if err := testSolve(t, photo.ProvingKeys, circuit, edited.Z.Img, editor.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same photograph edited with keys whose policy enables redact_gps, which the camera did not
+	photo.ProvingKeys.Policy = DefaultPolicy()
+	edited, circuit = testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	if err := testSolve(t, photo.ProvingKeys, circuit, edited.Z.Img, editor.PublicKey); err == nil {
+		t.Fatal("an edit was accepted under transformations that were not enabled at capture")
+	}
+}
+
+// A budget can neither go below zero nor be raised above the bound the camera signed
+func TestPhotoGnark_Budget(t *testing.T) {
+	img := testImage(t, "random")
+	img.Metadata.GPS = &image.GPS{Latitude: 40_443_322, Longitude: -79_943_041}
+	editorial := Policy{Name: "editorial", Transformations: []Policy_Entry{{Name: "identity", Bound: 1}, {Name: "redact_gps", Bound: 1}}}
+	_, photo := testOriginal(t, Mode_Public, editorial, img)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, circuit := testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+	if err := testSolve(t, photo.ProvingKeys, circuit, edited.Z.Img, editor.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same edit of a photograph captured without budget for redact_gps
+	editorial.Transformations[1].Bound = 0
+	_, photo = testOriginal(t, Mode_Public, editorial, img)
+	if _, err := photo.ProvingKeys.Policy.Consume(photo.Z.Img, "redact_gps", Redact_GPS_Tr_Params{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("consuming an exhausted budget returned %v, expected %v", err, ErrBudgetExceeded)
+	}
+	flags, params, err := assignTransformation(editorial, "redact_gps", Redact_GPS_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, circuit = testEdit(t, editor, photo, "identity", Identity_Tr_Params{})
+	circuit.Tr_Flags, circuit.Tr_Params = flags, params
+
+	// ... whose budget goes below zero
+	out := Redact_GPS_Tr{}.Apply(photo.Z.Img, nil)
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+	provenance := out.Provenance
+	circuit.Z_out.Img.Provenance[1].Tr_Bound = new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("an edit was accepted with a negative budget")
+	}
+
+	// ... or whose budget was raised to 1 before the edit
+	provenance[1].Tr_Bound = 1
+	in := photo.Z.Img
+	in.SetProvenance(provenance)
+	circuit.Z_in.Img = image.ImageToFr(in)
+	provenance[1].Tr_Bound = 0
+	out.SetProvenance(provenance)
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("an edit was accepted with a budget above the signed bound")
+	}
+}
+
+// Budgets are consumed across a chain of edits: an edit of an edit cannot spend what the first edit spent
+func TestPhotoGnark_CumulativeBudget(t *testing.T) {
+	img := testImage(t, "random")
+	img.Metadata.GPS = &image.GPS{Latitude: 40_443_322, Longitude: -79_943_041}
+	editorial := Policy{Name: "editorial", Transformations: []Policy_Entry{{Name: "identity", Bound: 1}, {Name: "redact_gps", Bound: 1}}}
+	_, photo := testOriginal(t, Mode_Public, editorial, img)
+	editor, err := NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, _ := testEdit(t, editor, photo, "redact_gps", Redact_GPS_Tr_Params{})
+	if _, err := editorial.Consume(edited.Z.Img, "redact_gps", Redact_GPS_Tr_Params{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("consuming a budget spent by the previous edit returned %v, expected %v", err, ErrBudgetExceeded)
+	}
+	flags, params, err := assignTransformation(editorial, "redact_gps", Redact_GPS_Tr_Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second redact_gps, whose budget goes below zero
+	_, circuit := testEdit(t, editor, edited, "identity", Identity_Tr_Params{})
+	circuit.Tr_Flags, circuit.Tr_Params = flags, params
+	out := Redact_GPS_Tr{}.Apply(edited.Z.Img, nil)
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+	circuit.Z_out.Img.Provenance[1].Tr_Bound = new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("an edit of an edit was accepted with a negative budget")
+	}
+
+	// ... or whose input's budget was reset to the signed bound, which is not the previous edit's output
+	provenance := edited.Z.Img.Provenance
+	provenance[1].Tr_Bound = 1
+	in := edited.Z.Img
+	in.SetProvenance(provenance)
+	circuit.Z_in.Img = image.ImageToFr(in)
+	provenance[1].Tr_Bound = 0
+	out.SetProvenance(provenance)
+	testResign(t, editor, photo.ProvingKeys, circuit, out)
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("an edit of an edit was accepted from a reset budget")
+	}
+
+	// ... nor the original, since the previous edit redacted the location the camera signed
+	circuit.Commitment_in = image.ImageCommitment(in)
+	circuit.Input_Original = 1
+	if err := testSolve(t, photo.ProvingKeys, circuit, out, editor.PublicKey); err == nil {
+		t.Fatal("an edit of an edit was accepted as an edit of the original with a reset budget")
+	}
+}
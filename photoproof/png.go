@@ -0,0 +1,201 @@
+package photoproof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*--------------------------------------------------PNG Embedding------------------------------------------------*/
+
+/*
+A bundle can travel inside its own image: the pixels are the PNG's pixels, everything else is stored in a
+private ancillary chunk, which viewers ignore. The chunk type "pgNK" reads as:
+
+	p: ancillary, the image displays without it,
+	g: private,
+	N: reserved bit, always uppercase,
+	K: unsafe to copy, editors that change the pixels must drop the proof.
+*/
+const Chunk_Type = "pgNK"
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Content of the chunk: the bundle without its pixels
+type embedded struct {
+	Provenance         [image.P]image.Provenance `json:"provenance"`
+	Metadata           image.Metadata            `json:"metadata"`
+	Original_PublicKey []byte                    `json:"original_public_key"`
+	Original_Signature []byte                    `json:"original_signature"`
+	Original_Hash      []byte                    `json:"original_hash"`
+
+	Capture   image.Capture          `json:"capture"`
+	Timestamp *image.Timestamp_Token `json:"timestamp,omitempty"`
+
+	PCD_Proof []byte `json:"pcd_proof"`
+	Signature []byte `json:"signature"`
+	PublicKey []byte `json:"public_key"`
+	Cameras   int    `json:"cameras,omitempty"`
+
+	Revocation_Epoch uint64 `json:"revocation_epoch,omitempty"`
+	Editors          int    `json:"editors,omitempty"`
+
+	Area *image.Area `json:"area,omitempty"`
+
+	Input_Commitment []byte        `json:"input_commitment,omitempty"`
+	Previous         []Bundle_Edit `json:"previous,omitempty"`
+
+	VerifyingKey_Fingerprint string `json:"verifying_key_fingerprint"`
+}
+
+// Write the bundle's image as a PNG, with the rest of the bundle embedded in a "pgNK" chunk
+func (bundle Bundle) WritePNG(w io.Writer) error {
+	var encoded bytes.Buffer
+	if err := bundle.Image.PNG(&encoded); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(embedded{
+		Provenance:               bundle.Image.Provenance,
+		Metadata:                 bundle.Image.Metadata,
+		Original_PublicKey:       bundle.Original_PublicKey,
+		Original_Signature:       bundle.Original_Signature,
+		Original_Hash:            bundle.Original_Hash,
+		Capture:                  bundle.Capture,
+		Timestamp:                bundle.Timestamp,
+		PCD_Proof:                bundle.PCD_Proof,
+		Signature:                bundle.Signature,
+		PublicKey:                bundle.PublicKey,
+		Cameras:                  bundle.Cameras,
+		Revocation_Epoch:         bundle.Revocation_Epoch,
+		Editors:                  bundle.Editors,
+		Area:                     bundle.Area,
+		Input_Commitment:         bundle.Input_Commitment,
+		Previous:                 bundle.Previous,
+		VerifyingKey_Fingerprint: bundle.VerifyingKey_Fingerprint,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The chunk goes right before IEND, the last 12 bytes of the encoder's output
+	png := encoded.Bytes()
+	iend := len(png) - 12
+	if iend < len(pngSignature) || string(png[iend+4:iend+8]) != "IEND" {
+		return fmt.Errorf("%w: PNG does not end with IEND", ErrDecode)
+	}
+	if _, err := w.Write(png[:iend]); err != nil {
+		return err
+	}
+	if err := writeChunk(w, Chunk_Type, data); err != nil {
+		return err
+	}
+	_, err = w.Write(png[iend:])
+	return err
+}
+
+// Read a PNG written by Bundle.WritePNG(). The bundle's image is made of the decoded pixels and the embedded
+// provenance, so verifying the bundle checks the proof against the pixels that are displayed.
+func ReadPNG(r io.Reader) (Bundle, error) {
+	png, err := io.ReadAll(r)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	data, err := findChunk(png, Chunk_Type)
+	if err != nil {
+		return Bundle{}, err
+	}
+	var chunk embedded
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return Bundle{}, Wrap(ErrDecode, err)
+	}
+
+	img, err := image.FromPNG(bytes.NewReader(png))
+	if err != nil {
+		return Bundle{}, Wrap(ErrDecode, err)
+	}
+	img.Metadata = chunk.Metadata
+	img.SetProvenance(chunk.Provenance)
+
+	return Bundle{
+		Image:                    img,
+		Original_PublicKey:       chunk.Original_PublicKey,
+		Original_Signature:       chunk.Original_Signature,
+		Original_Hash:            chunk.Original_Hash,
+		Capture:                  chunk.Capture,
+		Timestamp:                chunk.Timestamp,
+		PCD_Proof:                chunk.PCD_Proof,
+		Signature:                chunk.Signature,
+		PublicKey:                chunk.PublicKey,
+		Cameras:                  chunk.Cameras,
+		Revocation_Epoch:         chunk.Revocation_Epoch,
+		Editors:                  chunk.Editors,
+		Area:                     chunk.Area,
+		Input_Commitment:         chunk.Input_Commitment,
+		Previous:                 chunk.Previous,
+		VerifyingKey_Fingerprint: chunk.VerifyingKey_Fingerprint,
+	}, nil
+}
+
+// Read a PNG written by Bundle.WritePNG() and verify its embedded proof, against its decoded pixels,
+// with the trusted verifier keys
+func VerifyPNG(r io.Reader, trusted ...VerifierKeys) (Verdict, error) {
+	bundle, err := ReadPNG(r)
+	if err != nil {
+		return Verdict{}, err
+	}
+	return VerifyBundle(bundle, trusted...), nil
+}
+
+// Returns true if data starts with the PNG signature
+func IsPNG(data []byte) bool {
+	return bytes.HasPrefix(data, pngSignature)
+}
+
+func writeChunk(w io.Writer, chunk_type string, data []byte) error {
+	header := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	header = append(header, chunk_type...)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunk_type))
+	crc.Write(data)
+
+	for _, b := range [][]byte{header, data, binary.BigEndian.AppendUint32(nil, crc.Sum32())} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the data of the first chunk of the given type, after checking its CRC
+func findChunk(png []byte, chunk_type string) ([]byte, error) {
+	if !IsPNG(png) {
+		return nil, fmt.Errorf("%w: not a PNG", ErrDecode)
+	}
+
+	for rest := png[len(pngSignature):]; len(rest) >= 12; {
+		length := binary.BigEndian.Uint32(rest[:4])
+		if uint64(length) > uint64(len(rest)-12) {
+			break
+		}
+		typ, data := string(rest[4:8]), rest[8:8+length]
+		crc := binary.BigEndian.Uint32(rest[8+length : 12+length])
+
+		if typ == chunk_type {
+			if crc32.ChecksumIEEE(rest[4:8+length]) != crc {
+				return nil, fmt.Errorf("%w: %s chunk CRC mismatch", ErrDecode, chunk_type)
+			}
+			return data, nil
+		}
+		rest = rest[12+length:]
+	}
+
+	return nil, fmt.Errorf("%w: PNG has no %s chunk", ErrNoProof, chunk_type)
+}
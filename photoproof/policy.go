@@ -0,0 +1,145 @@
+package photoproof
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*------------------------------------------Permissible Transformation Policy------------------------------------*/
+
+/*
+A Policy is the Admin's declaration of which registered transformations are permissible and how much of each
+an image may undergo. It is passed to camera.Generator() and:
+  - compiled into the circuit: PhotoGnark only holds a flag & gadget for the enabled transformations,
+  - used by the camera to initialise Image.Provenance at capture, one entry per enabled transformation.
+
+Different customers can use different policies (e.g. forensic vs. editorial) from the same codebase.
+A policy file is JSON:
+
+	{
+		"name": "editorial",
+		"transformations": [
+			{ "name": "identity", "bound": 1 }
+		]
+	}
+*/
+type Policy struct {
+	Name            string         `json:"name"`
+	Transformations []Policy_Entry `json:"transformations"`
+}
+
+// An enabled transformation and its provenance bound
+type Policy_Entry struct {
+	Name  string `json:"name"`  // Registered name of the transformation
+	Bound uint64 `json:"bound"` // Provenance bound set by the camera
+}
+
+// Returns the policy enabling every registered transformation with its default bound
+func DefaultPolicy() Policy {
+	policy := Policy{Name: "default"}
+	for _, r := range Registered() {
+		policy.Transformations = append(policy.Transformations, Policy_Entry{Name: r.Name, Bound: r.Bound})
+	}
+	return policy
+}
+
+// Read and validate a JSON policy file
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	return ParsePolicy(data)
+}
+
+// Parse and validate a JSON policy. Its transformations are returned ordered by id.
+func ParsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	sort.SliceStable(policy.Transformations, func(i, j int) bool {
+		ri, _ := Lookup(policy.Transformations[i].Name)
+		rj, _ := Lookup(policy.Transformations[j].Name)
+		return ri.Id < rj.Id
+	})
+
+	if err := policy.Validate(); err != nil {
+		return Policy{}, err
+	}
+
+	return policy, nil
+}
+
+// Check that every transformation is registered and enabled once, in id order, that "identity" is enabled
+// (proofs of originality use it) and that every transformation has a provenance entry.
+func (policy Policy) Validate() error {
+	if len(policy.Transformations) > int(image.P) {
+		return fmt.Errorf("%w: %d transformations, images only have %d provenance entries", ErrInvalidPolicy, len(policy.Transformations), image.P)
+	}
+
+	identity := false
+	for i, entry := range policy.Transformations {
+		reg, ok := Lookup(entry.Name)
+		if !ok {
+			return fmt.Errorf("%w: %w: %q", ErrInvalidPolicy, ErrUnknownTransformation, entry.Name)
+		}
+		if i > 0 {
+			prev, _ := Lookup(policy.Transformations[i-1].Name)
+			if prev.Id >= reg.Id {
+				return fmt.Errorf("%w: %q is listed twice or out of id order", ErrInvalidPolicy, entry.Name)
+			}
+		}
+		identity = identity || reg.Id == Identity_Id
+	}
+
+	if !identity {
+		return fmt.Errorf("%w: \"identity\" must be enabled", ErrInvalidPolicy)
+	}
+
+	return nil
+}
+
+// Returns the enabled transformations' registrations, in policy order
+func (policy Policy) Registrations() []Registration {
+	regs := make([]Registration, len(policy.Transformations))
+	for i, entry := range policy.Transformations {
+		regs[i], _ = Lookup(entry.Name)
+	}
+	return regs
+}
+
+// Returns the index of the transformation in the policy, which is also the index of its provenance entry,
+// or -1 if it is not enabled.
+func (policy Policy) Slot(name string) int {
+	for i, entry := range policy.Transformations {
+		if entry.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the size of PhotoGnark's Tr_Params, the largest Nb_Params of the enabled transformations
+func (policy Policy) Nb_Params() int {
+	n := 0
+	for _, r := range policy.Registrations() {
+		n = max(n, r.Nb_Params)
+	}
+	return n
+}
+
+// Returns the provenance a camera sets on a new image: one entry per enabled transformation,
+// in policy order, with its bound.
+func (policy Policy) Provenance() [image.P]image.Provenance {
+	provenance := [image.P]image.Provenance{}
+	for i, reg := range policy.Registrations() {
+		provenance[i] = image.Provenance{Tr_Name: reg.Id, Tr_Bound: policy.Transformations[i].Bound}
+	}
+	return provenance
+}
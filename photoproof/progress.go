@@ -0,0 +1,95 @@
+package photoproof
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*--------------------------------------------Progress & Cancellation--------------------------------------------*/
+
+// A long-running phase of key generation or proof generation
+type Phase string
+
+const (
+	Phase_Compile Phase = "compile" // Compiling the circuit into a constraint system
+	Phase_Setup   Phase = "setup"   // Generating the PCD keys from the constraint system
+	Phase_Witness Phase = "witness" // Building the witness from the circuit assignment
+	Phase_Prove   Phase = "prove"   // Solving the constraint system and creating the proof
+)
+
+// Reported to an Observer when a phase starts and when it ends
+type Progress struct {
+	Phase    Phase
+	Finished bool          // false when the phase starts, true when it ends
+	Elapsed  time.Duration // Time spent in the phase, set when Finished
+	Err      error         // Set when Finished and the phase failed or was cancelled
+}
+
+// Receives the Progress of every phase run with a context returned by WithObserver().
+// It is called from the goroutine running the phase, so it must be safe for concurrent use
+// when the same context is shared, e.g. by a BatchProver.
+type Observer func(Progress)
+
+type observerKey struct{}
+
+// Returns a copy of ctx that reports the progress of every phase to observer
+func WithObserver(ctx context.Context, observer Observer) context.Context {
+	return context.WithValue(ctx, observerKey{}, observer)
+}
+
+func observe(ctx context.Context, progress Progress) {
+	if observer, ok := ctx.Value(observerKey{}).(Observer); ok && observer != nil {
+		observer(progress)
+	}
+}
+
+type pendingKey struct{}
+
+// Returns a copy of ctx in which every Step adds its computation to pending until it finishes, abandoned or not
+func withPending(ctx context.Context, pending *sync.WaitGroup) context.Context {
+	return context.WithValue(ctx, pendingKey{}, pending)
+}
+
+// Run fn as the given phase, reporting its progress to ctx's Observer.
+//
+// Gnark's compiler, setup and prover cannot be interrupted, so fn runs in its own goroutine and Step returns
+// ctx.Err() as soon as ctx is done. The abandoned computation finishes in the background and its result is discarded,
+// a BatchProver's worker waits for it before taking another job (see withPending()).
+func Step[T any](ctx context.Context, phase Phase, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	pending, _ := ctx.Value(pendingKey{}).(*sync.WaitGroup)
+	if pending != nil {
+		pending.Add(1)
+	}
+
+	observe(ctx, Progress{Phase: phase})
+	start := time.Now()
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1) // Buffered, so an abandoned fn can always return
+	go func() {
+		if pending != nil {
+			defer pending.Done()
+		}
+		value, err := fn()
+		done <- outcome{value, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out = outcome{zero, ctx.Err()}
+	}
+
+	observe(ctx, Progress{Phase: phase, Finished: true, Elapsed: time.Since(start), Err: out.err})
+
+	return out.value, out.err
+}
@@ -0,0 +1,42 @@
+package photoproof
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A cancelled Step returns at once, but its computation stays pending until it finishes
+func TestStep_Cancelled(t *testing.T) {
+	var pending sync.WaitGroup
+	ctx, cancel := context.WithCancel(withPending(context.Background(), &pending))
+
+	release := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := Step(ctx, Phase_Prove, func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled step returned %v, expected %v", err, context.Canceled)
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("an abandoned computation was not pending")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	<-waited
+}
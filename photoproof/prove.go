@@ -1,112 +1,297 @@
 package photoproof
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/signature"
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
 )
 
 //	to test
 //
 // Case 1: Original image
 // Case 2: Potentially edited image
-func (user User) Prove(photo_in Photograph, photo_out Photograph, tr Transformation, params Parameters) (groth16.Proof, error) {
-
-	// Assign the output signature to its eddsa equivilant
-	var eddsa_sig_out eddsa.Signature
-	eddsa_sig_out.Assign(1, photo_out.Proof.Signature)
+//
+// Proving stops with ctx.Err() when ctx is cancelled, see Step().
+func (user User) Prove(ctx context.Context, photo_in Photograph, photo_out Photograph, tr Transformation, params Parameters) (groth16.Proof, error) {
 
 	/* Case 1: Function was called by camera */
 	if photo_in.Proof.PCD_Proof == nil {
+		// Assign the output signature to its eddsa equivilant
+		var eddsa_sig_out eddsa.Signature
+		eddsa_sig_out.Assign(1, photo_out.Proof.Signature)
 
-		og_proof, err := ProveOriginal(photo_in, eddsa_sig_out) // Initial pcd_proof
+		og_proof, err := ProveOriginal(ctx, photo_in, eddsa_sig_out) // Initial pcd_proof
 		if err != nil {
-			fmt.Println("Error in ProveOriginal(), Prove()\n" + err.Error())
 			return nil, err
 		}
-		fmt.Println("********Proving Originality was successful...********")
-		return og_proof, err
+		Logger().Debug("proving originality was successful")
+		return og_proof, nil
 	}
 
-	var eddsa_pk_out eddsa.PublicKey
-	eddsa_pk_out.Assign(1, user.PublicKey.Bytes())
+	// Region keys only prove originals and their regions
+	if photo_in.ProvingKeys.Mode.Region() {
+		return nil, fmt.Errorf("%w: edits are not proven with Mode_Region, see ProveRegion()", ErrInvalidMode)
+	}
+
+	circuit, err := user.assignEdit(photo_in, photo_out, tr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return proveCircuit(ctx, photo_in.ProvingKeys, circuit, photo_out.Z.Img, user.PublicKey)
+}
 
-	/* Case 2: Else create a proof for the transformation */
-	circuit := PhotoGnark{}
-
-	switch tr.GetName() {
-	case "identity":
-		circuit = PhotoGnark{
-			Z_in:          photo_in.Z.ToFr(),
-			Z_out:         photo_out.Z.ToFr(),
-			PublicKey_out: eddsa_pk_out,
-			Signature_out: eddsa_sig_out,
-			Originality:   0, // Case 2: NOT original image
-
-			Parameters: params.ParamsToFr(),
-			Identity: Fr_Identity_Tr{
-				Flag: 1,
-			},
+// Case 1: This is an original photo.
+func ProveOriginal(ctx context.Context, photo_in Photograph, signature eddsa.Signature) (groth16.Proof, error) {
+	// With Mode_Region, the original is proven as the region covering the whole image
+	if photo_in.ProvingKeys.Mode.Region() {
+		if err := checkOriginal(photo_in); err != nil {
+			return nil, err
 		}
-	default:
-		fmt.Println("Transformation name is unknown")
+		return proveRegion(ctx, photo_in, photo_in.Z.Img, image.Whole_Area())
 	}
 
-	// Create the secret witness from the circuit
-	secret_witness_out, err := frontend.NewWitness(&circuit, ecc.BN254.ScalarField())
+	circuit, err := assignOriginal(photo_in, signature)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the security parameter and compile a constraint system (aka compliance_predicate)
-	compliance_predicate, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	return proveCircuit(ctx, photo_in.ProvingKeys, circuit, photo_in.Z.Img, nil)
+}
+
+// Returns ErrImageMismatch unless the original hash is the hash of the image and its capture,
+// or ErrInvalidSignature unless it is signed with the original public key
+func checkOriginal(photo_in Photograph) error {
+	if !bytes.Equal(photo_in.Z.Original_Hash, image.CaptureHash(photo_in.Z.Img, photo_in.Z.Capture)) {
+		return ErrImageMismatch
+	}
+	return VerifySignature(photo_in.ProvingKeys.Mode.Hash(), photo_in.Z.Original_PublicKey, photo_in.Z.Original_Hash, photo_in.Z.Original_Signature)
+}
+
+// Returns the PhotoGnark assignment proving the originality of photo_in, whose Z_out is signed with signature
+func assignOriginal(photo_in Photograph, signature eddsa.Signature) (*PhotoGnark, error) {
+	// The original hash must be the hash of the image and its capture, signed with the original public key
+	if err := checkOriginal(photo_in); err != nil {
+		return nil, err
+	}
+
+	cameras_root, camera_path, err := assignCamera(photo_in.ProvingKeys, photo_in.Z.Original_PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	revocation_root, revocation_proof, err := assignRevocation(photo_in.ProvingKeys, photo_in.Z.Original_PublicKey)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create proof_out that the secret witness adheres to the compliance predicate, using the given proving key
-	proof_out, err := groth16.Prove(compliance_predicate, photo_in.ProvingKeys.ProvingKey, secret_witness_out)
+	// An original image is related to itself by the identity transformation
+	tr_flags, tr_params, err := assignTransformation(photo_in.ProvingKeys.Policy, "identity", Identity_Tr_Params{})
 	if err != nil {
 		return nil, err
 	}
 
-	return proof_out, nil
+	// Construct a compliance predicate with Originality being set to true (or 1).
+	return &PhotoGnark{
+		Z_in:             photo_in.Z.ToFr(),
+		Z_out:            photo_in.Z.ToFr(),
+		PublicKey_out:    photo_in.Z.ToFr().Original_PublicKey,
+		Signature_out:    signature,
+		Originality:      1, // Original image
+		Commitment_in:    image.ImageCommitment(photo_in.Z.Img),
+		Input_Original:   1,
+		Cameras_Root:     cameras_root,
+		Camera_Path:      camera_path,
+		Revocation_Root:  revocation_root,
+		Revocation_Proof: revocation_proof,
+		Tr_Flags:         tr_flags,
+		Tr_Params:        tr_params,
+	}, nil
 }
 
-// Case 1: This is an original photo.
-func ProveOriginal(photo_in Photograph, signature eddsa.Signature) (groth16.Proof, error) {
-	// Construct a compliance predicate with Originality being set to true (or 1).
-	circuit := PhotoGnark{
-		Z_in:          photo_in.Z.ToFr(),
-		Z_out:         photo_in.Z.ToFr(),
-		PublicKey_out: photo_in.Z.ToFr().Original_PublicKey,
-		Signature_out: signature,
-		Originality:   1, // Original image
-		Parameters:    Fr_Identity_Tr_Params{},
-		Identity:      Fr_Identity_Tr{Flag: 1},
+// Returns the PhotoGnark assignment proving that photo_out, signed by the user, is photo_in edited with tr
+func (user User) assignEdit(photo_in Photograph, photo_out Photograph, tr Transformation, params Parameters) (*PhotoGnark, error) {
+	// The circuit binds Z_in to the original hash when it is the original image, to the output of the
+	// previous edit otherwise, whose proof is verified alongside this one (see Proof.Previous)
+	input_original := frontend.Variable(0)
+	if photo_in.Proof.Input_Commitment == nil {
+		if err := checkOriginal(photo_in); err != nil {
+			return nil, err
+		}
+		input_original = 1
+	}
+
+	// The photograph must have been taken by one of the Admin's cameras, which was not revoked
+	cameras_root, camera_path, err := assignCamera(photo_in.ProvingKeys, photo_in.Z.Original_PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	revocation_root, revocation_proof, err := assignRevocation(photo_in.ProvingKeys, photo_in.Z.Original_PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Z_out must be signed by this user, otherwise the circuit cannot be satisfied
+	hash := photo_in.ProvingKeys.Mode.Hash()
+	if err := VerifySignature(hash, user.PublicKey, hash.ImageHash(photo_out.Z.Img), photo_out.Proof.Signature); err != nil {
+		return nil, err
 	}
 
-	// Create the secret witness from the circuit (runs Define())
-	secret_witness_out, err := frontend.NewWitness(&circuit, ecc.BN254.ScalarField())
+	// Assign the output signature & key to their eddsa equivilant
+	var eddsa_sig_out eddsa.Signature
+	eddsa_sig_out.Assign(1, photo_out.Proof.Signature)
+	var eddsa_pk_out eddsa.PublicKey
+	eddsa_pk_out.Assign(1, user.PublicKey.Bytes())
+
+	/* Case 2: Else create a proof for the registered transformation */
+	tr_flags, tr_params, err := assignTransformation(photo_in.ProvingKeys.Policy, tr.GetName(), params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the security parameter and compile a constraint system (aka compliance_predicate) (runs Define())
-	compliance_predicate, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	return &PhotoGnark{
+		Z_in:             photo_in.Z.ToFr(),
+		Z_out:            photo_out.Z.ToFr(),
+		PublicKey_out:    eddsa_pk_out,
+		Signature_out:    eddsa_sig_out,
+		Originality:      0, // Case 2: NOT original image
+		Commitment_in:    image.ImageCommitment(photo_in.Z.Img),
+		Input_Original:   input_original,
+		Cameras_Root:     cameras_root,
+		Camera_Path:      camera_path,
+		Revocation_Root:  revocation_root,
+		Revocation_Proof: revocation_proof,
+
+		Tr_Flags:  tr_flags,
+		Tr_Params: tr_params,
+	}, nil
+}
+
+// Set the security parameter (BN254) and compile the constraint system (aka compliance_predicate)
+// of the given mode and policy
+func Compile(mode Mode, policy Policy) (constraint.ConstraintSystem, error) {
+	if err := mode.Validate(); err != nil {
+		return nil, err
+	}
+	compliance_predicate, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, NewCircuit(mode, policy))
+	if err != nil {
+		return nil, Wrap(ErrCompile, err)
+	}
+	return compliance_predicate, nil
+}
+
+// Prove the PhotoGnark assignment with the circuit of the proving key's mode, where img_out is Z_out's image
+// and editor the key that signed it, nil for an original image
+func proveCircuit(ctx context.Context, keys ProverKeys, circuit *PhotoGnark, img_out image.Image, editor signature.PublicKey) (groth16.Proof, error) {
+	assignment, err := assignMode(keys, circuit, img_out, editor)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create pcd_proof_original that the secret witness adheres to the compliance predicate, using the given proving key (runs Define())
-	pcd_proof_original, err := groth16.Prove(compliance_predicate, photo_in.ProvingKeys.ProvingKey, secret_witness_out)
+	return prove(ctx, keys, assignment)
+}
+
+// Returns the assignment of the circuit of the proving key's mode for a PhotoGnark assignment, see proveCircuit()
+func assignMode(keys ProverKeys, circuit *PhotoGnark, img_out image.Image, editor signature.PublicKey) (frontend.Circuit, error) {
+	if !keys.Mode.Private() {
+		return circuit, nil
+	}
+
+	editors_root, editor_path, err := assignEditor(keys, editor)
 	if err != nil {
 		return nil, err
 	}
+	return circuit.Private(image.ImageCommitment(img_out), keys.Mode, editors_root, editor_path), nil
+}
+
+// Prove the assignment of the circuit of the proving key's mode
+func prove(ctx context.Context, keys ProverKeys, assignment frontend.Circuit) (groth16.Proof, error) {
+	// Create the secret witness from the circuit
+	secret_witness_out, err := Step(ctx, Phase_Witness, func() (witness.Witness, error) {
+		return frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	})
+	if err != nil {
+		return nil, Wrap(ErrWitness, err)
+	}
+
+	// Set the security parameter and compile a constraint system (aka compliance_predicate) (runs Define()),
+	// unless the Admin already shared it alongside the proving key
+	compliance_predicate := keys.Compliance_Predicate
+	if compliance_predicate == nil {
+		compliance_predicate, err = Step(ctx, Phase_Compile, func() (constraint.ConstraintSystem, error) {
+			return Compile(keys.Mode, keys.Policy)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return pcd_proof_original, err
+	// Create proof_out that the secret witness adheres to the compliance predicate, using the given proving key
+	proof_out, err := Step(ctx, Phase_Prove, func() (groth16.Proof, error) {
+		return groth16.Prove(compliance_predicate, keys.ProvingKey, secret_witness_out)
+	})
+	if err != nil {
+		return nil, Wrap(ErrProve, err)
+	}
+
+	return proof_out, nil
+}
+
+// Returns the Cameras_Root & Camera_Path assignment of the camera's key, in the Admin's current set of cameras.
+// Returns ErrNotAuthorised if the camera is not in the set.
+func assignCamera(keys ProverKeys, camera signature.PublicKey) (frontend.Variable, Fr_Key_Path, error) {
+	path, err := keys.Cameras.Path(camera)
+	if err != nil {
+		return nil, Fr_Key_Path{}, err
+	}
+	root, err := keys.Cameras.Root()
+	if err != nil {
+		return nil, Fr_Key_Path{}, err
+	}
+	return root, path.ToFr(), nil
+}
+
+// Returns the Editors_Root & Editor_Path assignment of the editor's key, in the Admin's current set of editors.
+// Both are 0 unless the editor is hidden, the path is 0 for an original image, which its camera signs.
+// Returns ErrNotAuthorised if a hidden editor is not in the set.
+func assignEditor(keys ProverKeys, editor signature.PublicKey) (frontend.Variable, Fr_Key_Path, error) {
+	if !keys.Mode.Hidden_Editor() {
+		return 0, zeroPath(), nil
+	}
+
+	root, err := keys.Editors.Root()
+	if err != nil {
+		return nil, Fr_Key_Path{}, err
+	}
+	if editor == nil {
+		return root, zeroPath(), nil
+	}
+	path, err := keys.Editors.Path(editor)
+	if err != nil {
+		return nil, Fr_Key_Path{}, err
+	}
+	return root, path.ToFr(), nil
+}
+
+// Returns the Revocation_Root & Revocation_Proof assignment of the camera's key, in the Admin's current revocation list.
+// Returns ErrRevoked if the camera was revoked.
+func assignRevocation(keys ProverKeys, camera signature.PublicKey) (frontend.Variable, Fr_Non_Membership, error) {
+	proof, err := keys.Revocations.NonMembership(camera)
+	if err != nil {
+		return nil, Fr_Non_Membership{}, err
+	}
+	root, err := keys.Revocations.Root()
+	if err != nil {
+		return nil, Fr_Non_Membership{}, err
+	}
+	return root, proof.ToFr(), nil
 }
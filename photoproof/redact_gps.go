@@ -0,0 +1,82 @@
+package photoproof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*------------------------------------------GPS Redaction Transformation-----------------------------------------*/
+
+// "redact_gps" == 1
+const Redact_GPS_Id uint64 = 1
+
+func init() {
+	Register(Registration{
+		Name:           "redact_gps",
+		Id:             Redact_GPS_Id,
+		Bound:          1,
+		Transformation: Redact_GPS_Tr{},
+		Gadget:         Fr_Redact_GPS,
+		Nb_Params:      0,
+		Assign: func(params Parameters) ([]frontend.Variable, error) {
+			if _, ok := params.(Redact_GPS_Tr_Params); !ok {
+				return nil, errors.New("redact_gps expects Redact_GPS_Tr_Params")
+			}
+			return nil, nil
+		},
+		Parse_Params: func(data []byte) (Parameters, error) {
+			return Redact_GPS_Tr_Params{}, nil // redact_gps has no parameters
+		},
+		Applies: func(img image.Image, params Parameters) error {
+			if !img.Metadata.GPS_Recorded {
+				return fmt.Errorf("%w: redact_gps of an image without a recorded GPS location", ErrNotPermitted)
+			}
+			return nil
+		},
+	})
+}
+
+type Redact_GPS_Tr_Params struct {
+}
+
+func (params Redact_GPS_Tr_Params) GetName() string {
+	return "redact_gps"
+}
+
+// A "redact_gps" transformation strips the image's GPS location, e.g. to protect a source.
+// The location is still known to have been recorded, and the provenance shows that it was redacted.
+type Redact_GPS_Tr struct {
+}
+
+// Extend Transformation interface
+func (tr Redact_GPS_Tr) GetName() string {
+	return "redact_gps"
+}
+
+// Extend Transformation interface
+// Return the image without its GPS location
+func (tr Redact_GPS_Tr) Apply(img image.Image, params *Parameters) image.Image {
+	img.Metadata.GPS = nil
+	return img
+}
+
+/*-------------------------------------Gnark-Friendly GPS Redaction Transformation-------------------------------*/
+
+// [Gnark-friendly] A "redact_gps" transformation keeps every pixel, its metadata is checked by Check_Metadata().
+// It only applies to images whose camera recorded a location, so it cannot spend its budget on nothing.
+// return 0 if unsuccessful, 1 if successful
+func Fr_Redact_GPS(api frontend.API, circuit *PhotoGnark, params []frontend.Variable) frontend.Variable {
+	equal := frontend.Variable(0)
+	for i := range circuit.Z_in.Img.Pxls {
+		for c := range circuit.Z_in.Img.Pxls[i].RGB {
+			equal = api.Add(equal, api.IsZero(api.Sub(circuit.Z_in.Img.Pxls[i].RGB[c], circuit.Z_out.Img.Pxls[i].RGB[c])))
+		}
+	}
+
+	kept := api.IsZero(api.Sub(equal, 3*len(circuit.Z_in.Img.Pxls)))
+	recorded := api.IsZero(api.Sub(circuit.Z_in.Img.Metadata.GPS_Recorded, 1))
+	return api.Mul(kept, recorded)
+}
@@ -0,0 +1,205 @@
+package photoproof
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*-------------------------------------------------Region Proofs-------------------------------------------------*/
+
+/*
+A PCD proof covers every pixel of an image and every edit that led to it. Sometimes only part of a published image
+needs to be trusted, e.g. a face that was not retouched while the background was blurred. With Mode_Region, the
+Admin's keys prove regions instead of edits: PhotoGnark_Region shows that the pixels of a published image within a
+public area are those of an original image signed by an authorised, unrevoked camera, and nothing about the rest.
+
+Public inputs are:
+  - a commitment to the published image (see image.ImageCommitment),
+  - the area,
+  - the original public key, original hash and capture time & counter,
+  - the root of the authorised cameras,
+  - the root of the revoked cameras.
+
+The original image stays secret. The camera's own proof covers the whole image, see ProverKeys.Record().
+*/
+type PhotoGnark_Region struct {
+	Z_in               image.Fr_Z        `gnark:",secret"` // The original image
+	Img_out            image.Fr_Image    `gnark:",secret"` // The published image
+	Commitment_out     frontend.Variable `gnark:",public"`
+	Area               image.Fr_Area     `gnark:",public"`
+	Original_PublicKey eddsa.PublicKey   `gnark:",public"`
+	Original_Hash      frontend.Variable `gnark:",public"`
+	Capture            image.Fr_Capture  `gnark:",public"`
+	Cameras_Root       frontend.Variable `gnark:",public"`
+	Camera_Path        Fr_Key_Path       `gnark:",secret"`
+	Revocation_Root    frontend.Variable `gnark:",public"`
+	Revocation_Proof   Fr_Non_Membership `gnark:",secret"`
+
+	Hash image.Hash `gnark:"-"`
+}
+
+func (circuit *PhotoGnark_Region) Define(api frontend.API) error {
+	original := circuit.Z_in
+
+	// The original image is the one its authorised, unrevoked camera signed with its capture
+	image.Fr_AssertPxlBytes(api, original.Img)
+	api.AssertIsEqual(circuit.Original_Hash, image.Fr_CaptureHash(api, original.Img, original.Capture))
+	api.AssertIsEqual(circuit.Original_Hash, original.Original_Hash)
+	api.AssertIsEqual(circuit.Capture.Time, original.Capture.Time)
+	api.AssertIsEqual(circuit.Capture.Counter, original.Capture.Counter)
+	api.AssertIsEqual(circuit.Original_PublicKey.A.X, original.Original_PublicKey.A.X)
+	api.AssertIsEqual(circuit.Original_PublicKey.A.Y, original.Original_PublicKey.A.Y)
+	Verify_Signature(api, circuit.Hash, circuit.Original_Hash, original.Original_Signature, original.Original_PublicKey)
+	api.AssertIsEqual(Fr_KeySetRoot(api, original.Original_PublicKey, circuit.Camera_Path), circuit.Cameras_Root)
+	api.AssertIsEqual(Fr_RevocationRoot(api, original.Original_PublicKey, circuit.Revocation_Proof), circuit.Revocation_Root)
+
+	// Bind the secret published pixels to the public commitment, range checking them
+	image.Fr_AssertPxlBytes(api, circuit.Img_out)
+	api.AssertIsEqual(circuit.Commitment_out, image.Fr_ImageCommitment(api, circuit.Img_out))
+
+	// Within the area, the published pixels are the original ones
+	mask := circuit.Area.Mask(api)
+	for i := range mask {
+		for c := range circuit.Img_out.Pxls[i].RGB {
+			api.AssertIsEqual(api.Mul(mask[i], api.Sub(circuit.Img_out.Pxls[i].RGB[c], original.Img.Pxls[i].RGB[c])), 0)
+		}
+	}
+
+	return nil
+}
+
+// Returns the photograph of the published image, proven to have the pixels of the original photograph within
+// the area, with keys generated for Mode_Region. The original must be the camera's photograph, e.g. as captured.
+// Returns ErrImageMismatch if the images differ within the area.
+// Proving stops with ctx.Err() when ctx is cancelled.
+func ProveRegion(ctx context.Context, original Photograph, published image.Image, area image.Area) (Photograph, error) {
+	keys := original.ProvingKeys
+	if !keys.Mode.Region() {
+		return Photograph{}, fmt.Errorf("%w: region proofs require keys generated with Mode_Region", ErrInvalidMode)
+	}
+	if err := area.Validate(); err != nil {
+		return Photograph{}, Wrap(ErrInvalidArea, err)
+	}
+	if !bytes.Equal(original.Z.Original_Hash, image.CaptureHash(original.Z.Img, original.Z.Capture)) {
+		return Photograph{}, fmt.Errorf("%w: the photograph is not the camera's original", ErrImageMismatch)
+	}
+	if err := VerifySignature(keys.Mode.Hash(), original.Z.Original_PublicKey, original.Z.Original_Hash, original.Z.Original_Signature); err != nil {
+		return Photograph{}, err
+	}
+	if !area.Equal(original.Z.Img, published) {
+		return Photograph{}, fmt.Errorf("%w: the published image differs from the original within the area", ErrImageMismatch)
+	}
+	// The published image is not an edit: it keeps the original's provenance, which the proof says nothing about
+	published.SetProvenance(original.Z.Img.Provenance)
+
+	proof, err := proveRegion(ctx, original, published, area)
+	if err != nil {
+		return Photograph{}, err
+	}
+
+	photo := Photograph{
+		Z: image.Z{
+			Img:                published,
+			Original_PublicKey: original.Z.Original_PublicKey,
+			Original_Signature: original.Z.Original_Signature,
+			Original_Hash:      original.Z.Original_Hash,
+			Capture:            original.Z.Capture,
+			Timestamp:          original.Z.Timestamp,
+		},
+		Proof:         Proof{PCD_Proof: proof, Area: &area},
+		ProvingKeys:   keys,
+		VerifyingKeys: original.VerifyingKeys,
+	}
+	keys.Record(&photo.Proof)
+	return photo, nil
+}
+
+// Prove the PhotoGnark_Region assignment of the original photograph, the published image and the area
+func proveRegion(ctx context.Context, original Photograph, published image.Image, area image.Area) (groth16.Proof, error) {
+	circuit, err := assignRegion(original, published, area)
+	if err != nil {
+		return nil, err
+	}
+	return prove(ctx, original.ProvingKeys, circuit)
+}
+
+// Returns the PhotoGnark_Region assignment of the original photograph, the published image and the area
+func assignRegion(original Photograph, published image.Image, area image.Area) (*PhotoGnark_Region, error) {
+	keys := original.ProvingKeys
+	cameras_root, camera_path, err := assignCamera(keys, original.Z.Original_PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	revocation_root, revocation_proof, err := assignRevocation(keys, original.Z.Original_PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	z := original.Z.ToFr()
+	circuit := PhotoGnark_Region{
+		Z_in:               z,
+		Img_out:            image.ImageToFr(published),
+		Commitment_out:     image.ImageCommitment(published),
+		Area:               area.ToFr(),
+		Original_PublicKey: z.Original_PublicKey,
+		Original_Hash:      z.Original_Hash,
+		Capture:            z.Capture,
+		Cameras_Root:       cameras_root,
+		Camera_Path:        camera_path,
+		Revocation_Root:    revocation_root,
+		Revocation_Proof:   revocation_proof,
+	}
+	return &circuit, nil
+}
+
+// Out-of-circuit verification of a region proof, see Verify()
+func verifyRegion(photo Photograph, vk VerifierKeys) (bool, error) {
+	area := photo.Proof.Area
+	if area == nil {
+		return false, fmt.Errorf("%w: region proof without an area", ErrInvalidArea)
+	}
+	if err := area.Validate(); err != nil {
+		return false, Wrap(ErrInvalidArea, err)
+	}
+	if photo.Z.Original_PublicKey == nil {
+		return false, ErrKeyMismatch
+	}
+
+	cameras_root, err := camerasRoot(vk, photo.Proof.Cameras)
+	if err != nil {
+		return false, err
+	}
+	revocation_root, err := revocationRoot(vk, photo.Proof.Revocation_Epoch)
+	if err != nil {
+		return false, err
+	}
+
+	z := photo.Z.ToFr()
+	assignment := PhotoGnark_Region{
+		Commitment_out:     image.ImageCommitment(photo.Z.Img),
+		Area:               area.ToFr(),
+		Original_PublicKey: z.Original_PublicKey,
+		Original_Hash:      z.Original_Hash,
+		Capture:            z.Capture,
+		Cameras_Root:       cameras_root,
+		Revocation_Root:    revocation_root,
+	}
+
+	// Recreate the public witness from the public values
+	public_witness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		return false, Wrap(ErrWitness, err)
+	}
+	if err := groth16.Verify(photo.Proof.PCD_Proof, vk.VerifyingKey, public_witness); err != nil {
+		return false, Wrap(ErrInvalidProof, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package photoproof
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+// Returns nil if the assignment satisfies the PhotoGnark_Region circuit of the keys' mode & policy
+func testSolveRegion(t *testing.T, keys ProverKeys, circuit *PhotoGnark_Region) error {
+	t.Helper()
+	witness, err := frontend.NewWitness(circuit, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCompile(t, keys.Mode, keys.Policy).IsSolved(witness)
+}
+
+// Returns the region assignment of the published image within the area
+func testAssignRegion(t *testing.T, original Photograph, published image.Image, area image.Area) *PhotoGnark_Region {
+	t.Helper()
+	circuit, err := assignRegion(original, published, area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return circuit
+}
+
+func TestPhotoGnark_Region(t *testing.T) {
+	_, original := testOriginal(t, Mode_Region, DefaultPolicy(), testImage(t, "random"))
+	keys := original.ProvingKeys
+
+	// The top-left corner is published as captured, the bottom-right pixel is blacked out
+	area := image.Area{Loc: image.PixelLocation{X: 0, Y: 0}, Width: 2, Height: 2}
+	published := original.Z.Img
+	published.Pxls[image.To_1D_Index(image.N-1, image.N-1)].RGB = [3]uint8{}
+	published.PxlBytes = image.BigInt_to_Fr_Bytes(published)
+
+	if err := testSolveRegion(t, keys, testAssignRegion(t, original, published, area)); err != nil {
+		t.Fatal(err)
+	}
+	if err := testSolveRegion(t, keys, testAssignRegion(t, original, original.Z.Img, image.Whole_Area())); err != nil {
+		t.Fatalf("whole original: %v", err)
+	}
+
+	// A pixel within the area that is not the original's
+	forged := published
+	forged.Pxls[image.To_1D_Index(1, 1)].RGB[1] ^= 0xff
+	forged.PxlBytes = image.BigInt_to_Fr_Bytes(forged)
+	if err := testSolveRegion(t, keys, testAssignRegion(t, original, forged, area)); err == nil {
+		t.Error("a region with a pixel that is not the original's was accepted")
+	}
+
+	// An area that covers the blacked out pixel
+	if err := testSolveRegion(t, keys, testAssignRegion(t, original, published, image.Whole_Area())); err == nil {
+		t.Error("an area covering changed pixels was accepted")
+	}
+
+	// An area beyond the image's edge
+	circuit := testAssignRegion(t, original, published, area)
+	circuit.Area.Width = image.N + 1
+	if err := testSolveRegion(t, keys, circuit); err == nil {
+		t.Error("an area beyond the image's edge was accepted")
+	}
+
+	// An empty area, which proves nothing
+	circuit = testAssignRegion(t, original, published, area)
+	circuit.Area.Width = 0
+	if err := testSolveRegion(t, keys, circuit); err == nil {
+		t.Error("an empty area was accepted")
+	}
+
+	// A commitment to another image than the one proven
+	circuit = testAssignRegion(t, original, published, area)
+	circuit.Commitment_out = image.ImageCommitment(original.Z.Img)
+	if err := testSolveRegion(t, keys, circuit); err == nil {
+		t.Error("a region was accepted for another published image")
+	}
+
+	// An original that the camera did not sign
+	circuit = testAssignRegion(t, original, published, area)
+	circuit.Z_in.Img = image.ImageToFr(published)
+	if err := testSolveRegion(t, keys, circuit); err == nil {
+		t.Error("a region of an image that is not the signed original was accepted")
+	}
+}
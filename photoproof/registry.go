@@ -0,0 +1,159 @@
+package photoproof
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*-------------------------------------------Transformation Registry-------------------------------------------*/
+
+/*
+	Every permissible transformation registers itself from an init() function in its own file (see identity.go),
+	with everything the Admin, the provers and the verifiers need to know about it:
+
+		- its name and numeric id, which is the Tr_Name of its image.Provenance entry,
+		- its out-of-circuit implementation,
+		- its in-circuit gadget, checking that Z_out is the transformation of Z_in,
+		- how its Parameters are assigned to the circuit's Tr_Params,
+		- how much of its provenance budget one application consumes,
+		- which images it applies to, when not every image.
+
+	The Admin's Policy (see policy.go) enables some of the registered transformations. PhotoGnark holds one flag per
+	enabled transformation and a single parameter vector shared by all of them, so adding a transformation does not
+	require any change to PhotoGnark, Prove() or the camera.
+*/
+
+// [Gnark-friendly] In-circuit check that Z_out is the transformation of Z_in with the given parameters.
+//
+// A gadget returns 1 if the relation holds and 0 otherwise, instead of asserting it: every registered gadget is
+// part of the circuit, including those of transformations that were not applied. Assertions that hold for any
+// well-formed witness (e.g. range checks) are fine. circuit.Policy gives the transformation's provenance slot.
+type Gadget func(api frontend.API, circuit *PhotoGnark, params []frontend.Variable) frontend.Variable
+
+// Everything about a permissible transformation
+type Registration struct {
+	Name           string         // Same as Transformation.GetName() and Parameters.GetName()
+	Id             uint64         // Tr_Name of the transformation's Provenance entry
+	Bound          uint64         // Default provenance bound set by the camera
+	Transformation Transformation // Out-of-circuit implementation
+	Gadget         Gadget         // In-circuit implementation
+	Nb_Params      int            // Number of circuit variables used by the transformation's parameters
+
+	// Returns the circuit assignment of params, at most Nb_Params values
+	Assign func(params Parameters) ([]frontend.Variable, error)
+
+	// Returns the parameters encoded as JSON, e.g. on the command line. Empty data means default parameters.
+	Parse_Params func(data []byte) (Parameters, error)
+
+	// Provenance budget consumed by one application with the given parameters, out-of-circuit and in-circuit.
+	// Both must agree, and Fr_Cost must not assert since it runs for every enabled transformation.
+	// When nil, an application costs 1. See budget.go.
+	Cost    func(params Parameters) uint64
+	Fr_Cost func(api frontend.API, params []frontend.Variable) frontend.Variable
+
+	// Returns ErrNotPermitted if the transformation does not apply to img, which User.Edit() checks before
+	// applying it. The gadget must return 0 for the same images. When nil, every image is accepted.
+	Applies func(img image.Image, params Parameters) error
+}
+
+var registry = struct {
+	sync.RWMutex
+	byName map[string]Registration
+}{byName: map[string]Registration{}}
+
+// Register a permissible transformation. Meant to be called from init(), it panics if the name or id is taken.
+func Register(r Registration) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.byName[r.Name]; ok {
+		panic("photoproof: transformation " + r.Name + " is already registered")
+	}
+	for _, other := range registry.byName {
+		if other.Id == r.Id {
+			panic(fmt.Sprintf("photoproof: transformation id %d of %s is already used by %s", r.Id, r.Name, other.Name))
+		}
+	}
+
+	registry.byName[r.Name] = r
+}
+
+// Returns the registration of the transformation with the given name
+func Lookup(name string) (Registration, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	r, ok := registry.byName[name]
+	return r, ok
+}
+
+// Returns the parameters of the named transformation, decoded from JSON
+func ParseParameters(name string, data []byte) (Parameters, error) {
+	reg, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTransformation, name)
+	}
+
+	params, err := reg.Parse_Params(data)
+	if err != nil {
+		return nil, Wrap(ErrInvalidParameters, err)
+	}
+	return params, nil
+}
+
+// Returns every registered transformation, ordered by id.
+// This is the order of a Policy's transformations, so it is the same for the Admin, provers and verifiers.
+func Registered() []Registration {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	regs := make([]Registration, 0, len(registry.byName))
+	for _, r := range registry.byName {
+		regs = append(regs, r)
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i].Id < regs[j].Id })
+
+	return regs
+}
+
+// Returns the Tr_Flags & Tr_Params assignment of PhotoGnark compiled for policy,
+// for the named transformation and its parameters
+func assignTransformation(policy Policy, name string, params Parameters) ([]frontend.Variable, []frontend.Variable, error) {
+	reg, ok := Lookup(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownTransformation, name)
+	}
+	if policy.Slot(name) < 0 {
+		return nil, nil, fmt.Errorf("%w: %q by policy %q", ErrNotPermitted, name, policy.Name)
+	}
+	if params == nil || params.GetName() != name {
+		return nil, nil, fmt.Errorf("%w: %s expects its own parameters", ErrInvalidParameters, name)
+	}
+
+	flags := make([]frontend.Variable, len(policy.Transformations))
+	for i, entry := range policy.Transformations {
+		flags[i] = 0
+		if entry.Name == name {
+			flags[i] = 1
+		}
+	}
+
+	assigned, err := reg.Assign(params)
+	if err != nil {
+		return nil, nil, Wrap(ErrInvalidParameters, err)
+	}
+
+	fr_params := make([]frontend.Variable, policy.Nb_Params())
+	for i := range fr_params {
+		fr_params[i] = 0
+		if i < len(assigned) {
+			fr_params[i] = assigned[i]
+		}
+	}
+
+	return flags, fr_params, nil
+}
@@ -0,0 +1,80 @@
+package photoproof
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*------------------------------------------------Provenance Report----------------------------------------------*/
+
+// A human and machine readable account of an image's provenance, decoded with the Admin's setup policy
+type Report struct {
+	Policy          string         `json:"policy"`
+	Transformations []Report_Entry `json:"transformations"`
+}
+
+// One provenance entry of an image
+type Report_Entry struct {
+	Slot            int    `json:"slot"`            // Index of the entry in Image.Provenance
+	Name            string `json:"name"`            // Registered name of the transformation
+	Id              uint64 `json:"id"`              // Tr_Name of the entry
+	Original_Bound  uint64 `json:"original_bound"`  // Bound set by the camera, from the policy
+	Remaining_Bound uint64 `json:"remaining_bound"` // Tr_Bound of the entry, what is left to consume
+	Consumed        uint64 `json:"consumed"`        // Budget consumed by the edits so far
+}
+
+// Decode every provenance entry of img with the policy its keys were generated for.
+// Returns ErrPolicyMismatch if img's provenance was not laid out by that policy.
+func NewReport(img image.Image, policy Policy) (Report, error) {
+	report := Report{Policy: policy.Name, Transformations: []Report_Entry{}}
+	initial := policy.Provenance()
+
+	for i, prov := range img.Provenance {
+		if prov.Tr_Name != initial[i].Tr_Name || prov.Tr_Bound > initial[i].Tr_Bound {
+			return Report{}, fmt.Errorf("%w: provenance entry %d", ErrPolicyMismatch, i)
+		}
+		if i >= len(policy.Transformations) {
+			continue // Unused entry
+		}
+
+		report.Transformations = append(report.Transformations, Report_Entry{
+			Slot:            i,
+			Name:            policy.Transformations[i].Name,
+			Id:              prov.Tr_Name,
+			Original_Bound:  initial[i].Tr_Bound,
+			Remaining_Bound: prov.Tr_Bound,
+			Consumed:        initial[i].Tr_Bound - prov.Tr_Bound,
+		})
+	}
+
+	return report, nil
+}
+
+// Decode the photograph's provenance with the policy of its verifier keys
+func (photo Photograph) Report() (Report, error) {
+	return NewReport(photo.Z.Img, photo.VerifyingKeys.Policy)
+}
+
+// Render the report as a text table
+func (report Report) String() string {
+	var sb strings.Builder
+	sb.WriteString("Policy: " + report.Policy + "\n")
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tTRANSFORMATION\tORIGINAL\tREMAINING\tCONSUMED")
+	for _, entry := range report.Transformations {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\n", entry.Slot, entry.Name, entry.Original_Bound, entry.Remaining_Bound, entry.Consumed)
+	}
+	w.Flush()
+
+	return sb.String()
+}
+
+// Render the report as indented JSON
+func (report Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
@@ -0,0 +1,227 @@
+package photoproof
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+/*--------------------------------------------------Revocation List----------------------------------------------*/
+
+/*
+	A stolen camera is revoked by adding its key to the Admin's revocation list. The list's root is a public input
+	of the circuit, and the prover shows in-circuit that the camera's key is NOT in the list.
+
+	The list is a sorted Merkle tree of the revoked keys' leaves (see KeyLeaf()), of depth Key_Set_Depth,
+	between two sentinels:
+
+		0, revoked leaves in ascending order, p-1, p-1, ...
+
+	A key is not revoked if its leaf lies strictly between two adjacent leaves, low < leaf < high.
+
+	Every update of the list starts a new epoch. Revocations are only ever appended, so the list of any past epoch
+	can be recreated, and a proof records the epoch it was made against.
+*/
+
+// A revoked key and the epoch it was revoked in
+type Revocation struct {
+	Key   []byte `json:"key"` // Compressed EdDSA BN254 public key
+	Epoch uint64 `json:"epoch"`
+}
+
+// The Admin's list of revoked camera keys
+type Revocation_List struct {
+	Epoch       uint64       `json:"epoch"` // 0 until the first revocation
+	Revocations []Revocation `json:"revocations"`
+}
+
+// Proof that a key's leaf is between two adjacent leaves of a revocation list
+type Non_Membership struct {
+	Low, High           []byte
+	Low_Path, High_Path Key_Path
+}
+
+// Revoke the keys in a new epoch. Keys that are already revoked are ignored.
+func (list *Revocation_List) Revoke(keys ...signature.PublicKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	epoch := list.Epoch + 1
+	revocations := append([]Revocation{}, list.Revocations...)
+	for _, key := range keys {
+		if list.IsRevoked(key) {
+			continue
+		}
+		revocations = append(revocations, Revocation{Key: key.Bytes(), Epoch: epoch})
+	}
+
+	updated := Revocation_List{Epoch: epoch, Revocations: revocations}
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	*list = updated
+	return nil
+}
+
+// Returns true if the key is revoked
+func (list Revocation_List) IsRevoked(key signature.PublicKey) bool {
+	for _, revocation := range list.Revocations {
+		if key != nil && bytes.Equal(revocation.Key, key.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the list as it was at the given epoch
+func (list Revocation_List) At(epoch uint64) (Revocation_List, error) {
+	if epoch > list.Epoch {
+		return Revocation_List{}, fmt.Errorf("%w: revocation epoch %d is ahead of the list's epoch %d", ErrInvalidKeySet, epoch, list.Epoch)
+	}
+
+	past := Revocation_List{Epoch: epoch, Revocations: []Revocation{}}
+	for _, revocation := range list.Revocations {
+		if revocation.Epoch <= epoch {
+			past.Revocations = append(past.Revocations, revocation)
+		}
+	}
+	return past, nil
+}
+
+// Returns ErrInvalidKeySet if the list is too large, holds a malformed key or a revocation from a later epoch
+func (list Revocation_List) Validate() error {
+	// Two leaves are taken by the sentinels
+	if len(list.Revocations) > 1<<Key_Set_Depth-2 {
+		return fmt.Errorf("%w: %d revocations, at most %d", ErrInvalidKeySet, len(list.Revocations), 1<<Key_Set_Depth-2)
+	}
+	for i, revocation := range list.Revocations {
+		if _, err := KeyLeaf(revocation.Key); err != nil {
+			return fmt.Errorf("%w: revocation %d: %w", ErrInvalidKeySet, i, err)
+		}
+		if revocation.Epoch > list.Epoch {
+			return fmt.Errorf("%w: revocation %d is from epoch %d, after the list's epoch %d", ErrInvalidKeySet, i, revocation.Epoch, list.Epoch)
+		}
+	}
+	return nil
+}
+
+// Returns the root of the list's sorted Merkle tree
+func (list Revocation_List) Root() ([]byte, error) {
+	levels, _, err := list.tree()
+	if err != nil {
+		return nil, err
+	}
+	return levels[Key_Set_Depth][0], nil
+}
+
+// Returns the proof that the key is not revoked.
+// Returns ErrRevoked if it is.
+func (list Revocation_List) NonMembership(key signature.PublicKey) (Non_Membership, error) {
+	if key == nil {
+		return Non_Membership{}, ErrKeyMismatch
+	}
+	leaf, err := KeyLeaf(key.Bytes())
+	if err != nil {
+		return Non_Membership{}, err
+	}
+	levels, nb_leaves, err := list.tree()
+	if err != nil {
+		return Non_Membership{}, err
+	}
+
+	// The first leaf above the key's, the sentinels guarantee there is one
+	leaves := levels[0]
+	high := sort.Search(nb_leaves, func(i int) bool { return bytes.Compare(leaves[i], leaf) >= 0 })
+	if high == nb_leaves || bytes.Equal(leaves[high], leaf) {
+		return Non_Membership{}, ErrRevoked
+	}
+
+	return Non_Membership{
+		Low:       leaves[high-1],
+		High:      leaves[high],
+		Low_Path:  merklePath(levels, high-1),
+		High_Path: merklePath(levels, high),
+	}, nil
+}
+
+// Returns every level of the sorted Merkle tree and the number of leaves up to the upper sentinel (included)
+func (list Revocation_List) tree() ([][][]byte, int, error) {
+	if err := list.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	var max fr.Element
+	max.SetOne()
+	max.Neg(&max) // p-1
+	upper := max.Marshal()
+
+	// Field elements marshal as big-endian, so bytes order is the order of the values
+	leaves := [][]byte{}
+	for _, revocation := range list.Revocations {
+		leaf, err := KeyLeaf(revocation.Key)
+		if err != nil {
+			return nil, 0, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	sort.Slice(leaves, func(i, j int) bool { return bytes.Compare(leaves[i], leaves[j]) < 0 })
+	leaves = append(append([][]byte{make([]byte, 32)}, leaves...), upper)
+
+	return merkleTree(leaves, upper), len(leaves), nil
+}
+
+// Write the list as JSON, e.g. to publish it
+func (list Revocation_List) WriteTo(w io.Writer) (int64, error) {
+	return writeJSON(w, list)
+}
+
+// Read a list written by Revocation_List.WriteTo()
+func ReadRevocationList(r io.Reader) (Revocation_List, error) {
+	var list Revocation_List
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return Revocation_List{}, Wrap(ErrDecode, err)
+	}
+	return list, list.Validate()
+}
+
+/*----------------------------------------------------In-Circuit-------------------------------------------------*/
+
+// [Gnark-friendly] Non_Membership
+type Fr_Non_Membership struct {
+	Low, High           frontend.Variable
+	Low_Path, High_Path Fr_Key_Path
+}
+
+// Returns the Fr_Non_Membership of the proof
+func (proof Non_Membership) ToFr() Fr_Non_Membership {
+	return Fr_Non_Membership{
+		Low:       proof.Low,
+		High:      proof.High,
+		Low_Path:  proof.Low_Path.ToFr(),
+		High_Path: proof.High_Path.ToFr(),
+	}
+}
+
+// [In-Circuit] Returns the root of the revocation list in which key is proven not to be revoked:
+// Low and High are adjacent leaves of the same tree, with Low < leaf < High.
+func Fr_RevocationRoot(api frontend.API, key eddsa.PublicKey, proof Fr_Non_Membership) frontend.Variable {
+	leaf := Fr_KeyLeaf(api, key)
+
+	api.AssertIsEqual(api.Cmp(proof.Low, leaf), -1)
+	api.AssertIsEqual(api.Cmp(leaf, proof.High), -1)
+
+	// Adjacent leaves of the same tree
+	api.AssertIsEqual(api.Add(proof.Low_Path.Index, 1), proof.High_Path.Index)
+	root := Fr_MerkleRoot(api, proof.Low, proof.Low_Path)
+	api.AssertIsEqual(root, Fr_MerkleRoot(api, proof.High, proof.High_Path))
+
+	return root
+}
@@ -1,8 +1,11 @@
 package photoproof
 
 import (
+	"slices"
+
 	"github.com/consensys/gnark-crypto/signature"
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
 	"github.com/drakstik/PhotoGnark_ACDF/image"
 )
 
@@ -10,18 +13,103 @@ import (
 type Proof struct {
 	PCD_Proof groth16.Proof
 	Signature []byte
+	PublicKey signature.PublicKey // Public key of the user who signed the image, PublicKey_out
+	Cameras   int                 // Number of authorised cameras when proving, see Key_Set.Prefix()
+
+	Revocation_Epoch uint64 // Epoch of the revocation list when proving, see Revocation_List.At()
+	Editors          int    // Number of authorised editors when proving, with Mode_Hidden_Editor
+
+	Area *image.Area // With Mode_Region, the only pixels proven to be the original's
+
+	// Commitment to the image the last edit was applied to (see image.ImageCommitment), nil for an original image
+	Input_Commitment []byte
+	// Proofs of the edits before the last one, oldest first, empty if the last edit was applied to the original
+	Previous []Edit_Proof
+}
+
+// The proof of one edit of a photograph, and the commitment to the image it output, which is
+// the next edit's input. Verifying a photograph verifies every edit, since proofs are not recursive.
+type Edit_Proof struct {
+	PCD_Proof groth16.Proof
+	PublicKey signature.PublicKey // Public key of the editor, nil with Mode_Hidden_Editor
+	Cameras   int
+
+	Revocation_Epoch uint64
+	Editors          int
+
+	Input_Commitment []byte
+	Commitment       []byte       // Commitment to the image output by the edit
+	Image            *image.Image // The image output by the edit with Mode_Public, whose public inputs are its pixels
 }
 
 // Prover keys from the Admin
 type ProverKeys struct {
 	ProvingKey         groth16.ProvingKey
 	Original_PublicKey signature.PublicKey
+	Mode               Mode            // Circuit the ProvingKey was generated for
+	Policy             Policy          // Admin's policy compiled into the circuit
+	Cameras            Key_Set         // Cameras authorised by the Admin, whose photographs can be proven
+	Revocations        Revocation_List // Cameras revoked by the Admin, whose photographs can no longer be proven
+	Editors            Key_Set         // Editors authorised by the Admin, with Mode_Hidden_Editor
+
+	// Compiled circuit the ProvingKey was generated for, so provers do not recompile it for every proof.
+	// When nil, the circuit of the given Mode and Policy is compiled before proving.
+	Compliance_Predicate constraint.ConstraintSystem
 }
 
 // Verifier keys from the Admin
 type VerifierKeys struct {
 	VerifyingKey       groth16.VerifyingKey
 	Original_PublicKey signature.PublicKey
+	Mode               Mode            // Circuit the VerifyingKey was generated for
+	Policy             Policy          // Admin's policy compiled into the circuit
+	Cameras            Key_Set         // Cameras authorised by the Admin, proofs are verified against its root
+	Revocations        Revocation_List // Cameras revoked by the Admin, proofs are verified against its root at their epoch
+	Editors            Key_Set         // Editors authorised by the Admin, with Mode_Hidden_Editor
+
+	// Number of epochs a proof's revocation list may lag behind Revocations, 0 if proofs must be made against
+	// the latest list. Proofs of older epochs are stale: they do not show that a hidden camera was not revoked since.
+	Revocation_Window uint64
+}
+
+// Record in the proof which cameras, revocations and editors it is made against.
+// With Mode_Region, a proof without an area is the camera's, which covers the whole image.
+func (keys ProverKeys) Record(proof *Proof) {
+	proof.Cameras = len(keys.Cameras.Keys)
+	proof.Revocation_Epoch = keys.Revocations.Epoch
+	if keys.Mode.Hidden_Editor() {
+		proof.Editors = len(keys.Editors.Keys)
+	}
+	if keys.Mode.Region() && proof.Area == nil {
+		whole := image.Whole_Area()
+		proof.Area = &whole
+	}
+}
+
+// Returns the proofs of every edit of the proven photograph, oldest first, the last one being its own.
+// Returns nil for an original image. With Mode_Public, the edits reveal every image output along the way.
+func (photo Photograph) Edits() []Edit_Proof {
+	if photo.Proof.Input_Commitment == nil {
+		return nil
+	}
+
+	edit := Edit_Proof{
+		PCD_Proof:        photo.Proof.PCD_Proof,
+		PublicKey:        photo.Proof.PublicKey,
+		Cameras:          photo.Proof.Cameras,
+		Revocation_Epoch: photo.Proof.Revocation_Epoch,
+		Editors:          photo.Proof.Editors,
+		Input_Commitment: photo.Proof.Input_Commitment,
+		Commitment:       image.ImageCommitment(photo.Z.Img),
+	}
+	if !photo.ProvingKeys.Mode.Private() {
+		img := photo.Z.Img
+		edit.Image = &img
+	}
+	if photo.ProvingKeys.Mode.Hidden_Editor() {
+		edit.PublicKey = nil
+	}
+	return append(slices.Clone(photo.Proof.Previous), edit)
 }
 
 // This is what is shared from node to node.
@@ -30,4 +118,7 @@ type Photograph struct {
 	Proof         Proof
 	ProvingKeys   ProverKeys
 	VerifyingKeys VerifierKeys
+
+	// Optional log of every step since the capture, see StartCustody(). Edit() appends to a copy of it.
+	Custody *Custody_Log
 }
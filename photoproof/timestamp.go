@@ -0,0 +1,38 @@
+package photoproof
+
+import (
+	"fmt"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*----------------------------------------------------Timestamp--------------------------------------------------*/
+
+/*
+	A camera's clock can be set to any time. A camera with a Timestamper sends the capture digest of the image to
+	a timestamp authority instead, which sets the capture time and signs the resulting original hash, as RFC 3161
+	timestamp authorities sign the hashes they are sent. The capture time is hashed into the original hash, which
+	the PCD proof carries unchanged, so the token holds for every photograph edited from the original.
+*/
+
+// Verify z's timestamp token: it is the authority's signature of z's original hash, at z's capture time.
+// Returns ErrInvalidTimestamp if z has no token or it does not hold. Whether the authority is trusted is up to
+// the caller.
+func VerifyTimestamp(z image.Z) error {
+	token := z.Timestamp
+	if token == nil {
+		return fmt.Errorf("%w: photograph has no timestamp token", ErrInvalidTimestamp)
+	}
+	if token.Time != z.Capture.Time {
+		return fmt.Errorf("%w: token is for time %d, not the capture time %d", ErrInvalidTimestamp, token.Time, z.Capture.Time)
+	}
+
+	authority, err := DecodePublicKey(token.Authority)
+	if err != nil {
+		return Wrap(ErrInvalidTimestamp, err)
+	}
+	if err := VerifySignature(image.Hash_MiMC, authority, image.TimestampDigest(z.Original_Hash, token.Serial), token.Signature); err != nil {
+		return Wrap(ErrInvalidTimestamp, err)
+	}
+	return nil
+}
@@ -3,49 +3,168 @@ package photoproof
 import (
 	"crypto/rand"
 	"fmt"
+	stdhash "hash"
 
-	"github.com/consensys/gnark-crypto/hash"
+	eddsa_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
 	"github.com/consensys/gnark-crypto/signature"
 	ceddsa "github.com/consensys/gnark-crypto/signature/eddsa"
 	"github.com/drakstik/PhotoGnark_ACDF/image"
 )
 
 type User struct {
-	SecretKey signature.Signer
+	Signer    Signer // Holds the secret key, in this process or elsewhere
 	PublicKey signature.PublicKey
 }
 
-func NewUser() User {
+// Signs digests with a secret key that may live outside this process, e.g. in a signing daemon (see signerd)
+// or an HSM. A gnark-crypto EdDSA BN254 secret key is a Signer.
+type Signer interface {
+	Public() signature.PublicKey
+	Sign(message []byte, hFunc stdhash.Hash) ([]byte, error)
+}
+
+// A Signer that signs the messages of this package rather than arbitrary digests: it hashes every message
+// itself, so that whoever asks cannot use its key as a signing oracle (see signerd). User.Sign(),
+// User.SignCapture() and Custody_Log.Append() hand it the message when the user's Signer is one.
+type Message_Signer interface {
+	Signer
+	SignMessage(h image.Hash, message Signed_Message) ([]byte, error)
+}
+
+// A message signed by a User. Exactly one of Image and Custody is set.
+type Signed_Message struct {
+	Image   *image.Image
+	Capture *image.Capture // Set with Image to sign its capture hash, the original hash, instead of its image hash
+	Custody *Custody_Entry
+}
+
+// Returns the digest of the message that is signed with h: the image hash, the capture hash or the custody
+// entry's digest. An image's PxlBytes are recomputed from its pixels & provenance.
+func (message Signed_Message) Digest(h image.Hash) ([]byte, error) {
+	switch {
+	case message.Custody != nil && message.Image == nil:
+		return message.Custody.Digest()
+	case message.Image != nil && message.Custody == nil:
+		img := *message.Image
+		img.PxlBytes = image.BigInt_to_Fr_Bytes(img)
+		if message.Capture != nil {
+			return image.CaptureHash(img, *message.Capture), nil
+		}
+		return h.ImageHash(img), nil
+	}
+	return nil, fmt.Errorf("%w: a signed message holds either an image or a custody entry", ErrSign)
+}
+
+func NewUser() (User, error) {
 	// 1. Generate a secret & public key using ceddsa.
 	secret_key, err := ceddsa.New(1, rand.Reader) // Generate a secret key for signing
 	if err != nil {
-		fmt.Println("func NewSecretKey(): Error while generating secret key using ceddsa...")
-		fmt.Print(err.Error())
-		return User{}
+		return User{}, Wrap(ErrKeyGeneration, err)
 	}
 
+	return NewUserWithSigner(secret_key), nil
+}
+
+// Returns the user whose secret key is held by signer
+func NewUserWithSigner(signer Signer) User {
 	return User{
-		SecretKey: secret_key,
-		PublicKey: secret_key.Public(),
+		Signer:    signer,
+		PublicKey: signer.Public(),
+	}
+}
+
+// Returns the user whose secret key was serialised with SecretKeyBytes()
+func NewUserFromBytes(secret_key []byte) (User, error) {
+	key := new(eddsa_bn254.PrivateKey)
+	if _, err := key.SetBytes(secret_key); err != nil {
+		return User{}, Wrap(ErrDecode, err)
+	}
+
+	return NewUserWithSigner(key), nil
+}
+
+// Returns the serialised secret key, unless it is held by a Signer outside this process
+func (user User) SecretKeyBytes() ([]byte, error) {
+	key, ok := user.Signer.(interface{ Bytes() []byte })
+	if !ok {
+		return nil, ErrExternalSigner
 	}
+	return key.Bytes(), nil
+}
 
+// Returns the fingerprint of the user's public key, which identifies the user across sessions
+func (user User) Fingerprint() string {
+	return Fingerprint(user.PublicKey.Bytes())
+}
+
+// Decode a public key serialised with PublicKey.Bytes()
+func DecodePublicKey(public_key []byte) (signature.PublicKey, error) {
+	key := new(eddsa_bn254.PublicKey)
+	if _, err := key.SetBytes(public_key); err != nil {
+		return nil, Wrap(ErrDecode, err)
+	}
+	return key, nil
 }
 
 // Out-of-circuit signing function,
-// Hashes the image using ImageHash and signs it with the user's secret key.
-func (user User) Sign(img image.Image) ([]byte, error) {
-	// Hash the img
-	digest := image.ImageHash(img)
+// Hashes the image using h.ImageHash() and signs it with the user's secret key, hashing with h too.
+// h is the hash function of the Admin's keys, see Mode.Hash().
+func (user User) Sign(h image.Hash, img image.Image) ([]byte, error) {
+	return user.signMessage(h, Signed_Message{Image: &img})
+}
+
+// Out-of-circuit signing of the capture hash of an image, see image.CaptureHash()
+func (user User) SignCapture(h image.Hash, img image.Image, capture image.Capture) ([]byte, error) {
+	return user.signMessage(h, Signed_Message{Image: &img, Capture: &capture})
+}
 
-	// Instantiate MIMC BN254 hash function, to be used in signing the image
-	hFunc := hash.MIMC_BN254.New()
+// Signs the message with a Message_Signer, or its digest with any other Signer
+func (user User) signMessage(h image.Hash, message Signed_Message) ([]byte, error) {
+	signer, ok := user.Signer.(Message_Signer)
+	if !ok {
+		digest, err := message.Digest(h)
+		if err != nil {
+			return nil, err
+		}
+		return user.SignDigest(h, digest)
+	}
+
+	signature, err := signer.SignMessage(h, message)
+	if err != nil {
+		return nil, Wrap(ErrSign, err)
+	}
+	return signature, nil
+}
+
+// Out-of-circuit signing of a digest that is already a BN254 field element, e.g. a timestamp digest.
+// A Message_Signer may refuse to sign digests, use Sign() or SignCapture() for images.
+func (user User) SignDigest(h image.Hash, digest []byte) ([]byte, error) {
+	// Instantiate the hash function, to be used in signing the digest
+	hFunc := h.New()
 
 	// Sign the digest with the hash function
-	signature, err := user.SecretKey.Sign(digest, hFunc)
+	signature, err := user.Signer.Sign(digest, hFunc)
 	if err != nil {
-		fmt.Println("Error while signing image: " + err.Error())
-		return nil, err
+		return nil, Wrap(ErrSign, err)
+	}
+
+	return signature, nil
+}
+
+// Out-of-circuit signature verification, mirroring Verify_Signature().
+// Returns ErrInvalidSignature if sig is not public_key's signature of digest, hashed with h.
+func VerifySignature(h image.Hash, public_key signature.PublicKey, digest []byte, sig []byte) error {
+	if public_key == nil {
+		return Wrap(ErrInvalidSignature, ErrKeyMismatch)
+	}
+
+	ok, err := public_key.Verify(sig, digest, h.New())
+	if err != nil {
+		return Wrap(ErrInvalidSignature, err)
+	}
+	if !ok {
+		return ErrInvalidSignature
 	}
 
-	return signature, err
+	return nil
 }
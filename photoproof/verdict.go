@@ -0,0 +1,130 @@
+package photoproof
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+/*-----------------------------------------------------Verdict---------------------------------------------------*/
+
+// The outcome of verifying a bundle, with its provenance report when it is valid
+type Verdict struct {
+	Valid        bool   `json:"valid"`
+	Error        string `json:"error,omitempty"`        // Why the bundle is invalid
+	VerifyingKey string `json:"verifying_key"`          // Fingerprint of the verifying key the bundle refers to
+	Cameras_Root string `json:"cameras_root,omitempty"` // Hex encoded root of the authorised cameras the proof was checked against
+	Camera       string `json:"camera,omitempty"`       // Fingerprint of the camera's key, unless it is hidden
+	Editor       string `json:"editor,omitempty"`       // Fingerprint of the last editor's key, unless it is hidden
+	Editors_Root string `json:"editors_root,omitempty"` // Hex encoded root of the authorised editors, when the editor is hidden
+
+	Revocation_Epoch  uint64 `json:"revocation_epoch"`  // Epoch of the revocation list the proof was checked against
+	Revocation_Latest uint64 `json:"revocation_latest"` // Latest epoch of the verifier's revocation list
+	Revoked           bool   `json:"revoked,omitempty"` // The camera was revoked, so the bundle is invalid
+
+	Captured            time.Time `json:"captured"`                      // Capture time, signed by the camera
+	Counter             uint64    `json:"counter,omitempty"`             // The camera's capture counter, 0 if it does not count
+	Timestamp_Authority string    `json:"timestamp_authority,omitempty"` // Fingerprint of the authority that set Captured, empty if the camera's clock did
+
+	Metadata     image.Metadata `json:"metadata"`
+	GPS_Redacted bool           `json:"gps_redacted,omitempty"` // The camera recorded a location, which was redacted since
+
+	Area *image.Area `json:"area,omitempty"` // With Mode_Region, the only pixels proven to be the original's
+
+	Provenance *Report `json:"provenance,omitempty"`
+
+	Err error `json:"-"` // Same as Error, for errors.Is()
+}
+
+// Verify the bundle with the trusted verifier keys it refers to.
+// A bundle referring to a verifying key that is not trusted is invalid (ErrKeyMismatch).
+func VerifyBundle(bundle Bundle, trusted ...VerifierKeys) Verdict {
+	verdict := Verdict{VerifyingKey: bundle.VerifyingKey_Fingerprint}
+
+	vk, err := findTrusted(bundle.VerifyingKey_Fingerprint, trusted)
+	if err != nil {
+		return verdict.invalid(err)
+	}
+
+	photo, err := bundle.Photograph(ProverKeys{}, *vk)
+	if err != nil {
+		return verdict.invalid(err)
+	}
+	verdict.Revocation_Epoch = photo.Proof.Revocation_Epoch
+	verdict.Revocation_Latest = vk.Revocations.Epoch
+	if _, err := Verify(photo, *vk); err != nil {
+		verdict.Revoked = errors.Is(err, ErrRevoked)
+		return verdict.invalid(err)
+	}
+
+	// The capture time is proven, a timestamp token must also hold for it
+	if photo.Z.Timestamp != nil {
+		if err := VerifyTimestamp(photo.Z); err != nil {
+			return verdict.invalid(err)
+		}
+		verdict.Timestamp_Authority = Fingerprint(photo.Z.Timestamp.Authority)
+	}
+
+	root, err := camerasRoot(*vk, photo.Proof.Cameras)
+	if err != nil {
+		return verdict.invalid(err)
+	}
+	if photo.Z.Original_PublicKey != nil && !vk.Mode.Hidden_Camera() {
+		verdict.Camera = Fingerprint(photo.Z.Original_PublicKey.Bytes())
+	}
+
+	verdict.Valid = true
+	verdict.Cameras_Root = hex.EncodeToString(root)
+	verdict.Captured = time.Unix(int64(photo.Z.Capture.Time), 0).UTC()
+	verdict.Counter = photo.Z.Capture.Counter
+
+	// Only the area of a region proof is the original's: edits, metadata and provenance are not proven
+	if vk.Mode.Region() {
+		verdict.Area = photo.Proof.Area
+		return verdict
+	}
+
+	report, err := NewReport(photo.Z.Img, vk.Policy)
+	if err != nil {
+		return verdict.invalid(err)
+	}
+
+	if vk.Mode.Hidden_Editor() {
+		root, err := editorsRoot(*vk, photo.Proof.Editors)
+		if err != nil {
+			return verdict.invalid(err)
+		}
+		verdict.Editors_Root = hex.EncodeToString(root)
+	} else {
+		verdict.Editor = Fingerprint(photo.Proof.PublicKey.Bytes())
+	}
+
+	verdict.Metadata = photo.Z.Img.Metadata
+	verdict.GPS_Redacted = photo.Z.Img.Metadata.GPS_Redacted()
+	verdict.Provenance = &report
+	return verdict
+}
+
+// Returns the trusted verifier keys with the given fingerprint, or ErrKeyMismatch if none has it
+func findTrusted(fingerprint string, trusted []VerifierKeys) (*VerifierKeys, error) {
+	for i := range trusted {
+		f, err := trusted[i].Fingerprint()
+		if err != nil {
+			return nil, err
+		}
+		if f == fingerprint {
+			return &trusted[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: verifying key %s is not trusted", ErrKeyMismatch, fingerprint)
+}
+
+func (verdict Verdict) invalid(err error) Verdict {
+	verdict.Valid = false
+	verdict.Error = err.Error()
+	verdict.Err = err
+	return verdict
+}
@@ -0,0 +1,193 @@
+package photoproof
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+)
+
+// Out-of-circuit verification of a Photograph's PCD proof, using the Admin's verifier keys.
+//
+// Only the public values are recreated. In Mode_Public those are the full Z_out, in Mode_Private the
+// commitment to the delivered image is recomputed here, which keeps the public witness constant-size.
+// The roots of the Admin's authorised and revoked cameras are always public values.
+//
+// A photograph edited more than once carries the proofs of its previous edits (see Proof.Previous), which are
+// verified too: each edit is proven from the output of the previous one.
+func Verify(photo Photograph, vk VerifierKeys) (bool, error) {
+	if photo.Proof.PCD_Proof == nil {
+		return false, ErrNoProof
+	}
+
+	// The image must be the one that was hashed & signed
+	if !bytes.Equal(photo.Z.Img.PxlBytes, image.BigInt_to_Fr_Bytes(photo.Z.Img)) {
+		return false, ErrImageMismatch
+	}
+
+	// A camera revoked since the proof was made is caught here when its key is known, by the freshness
+	// of the proof's revocation list otherwise (see revocationRoot())
+	if photo.Z.Original_PublicKey != nil && vk.Revocations.IsRevoked(photo.Z.Original_PublicKey) {
+		return false, ErrRevoked
+	}
+	if vk.Mode.Region() {
+		return verifyRegion(photo, vk)
+	}
+
+	// The original public key is proven to be one of the Admin's cameras, unless the camera is hidden
+	// it must be known to recreate the public inputs
+	if photo.Z.Original_PublicKey == nil && !vk.Mode.Hidden_Camera() {
+		return false, ErrKeyMismatch
+	}
+	revocation_root, err := revocationRoot(vk, photo.Proof.Revocation_Epoch)
+	if err != nil {
+		return false, err
+	}
+
+	// The last edit was applied to the output of the previous one, or to the original image
+	last := Edit_Proof{
+		PCD_Proof:        photo.Proof.PCD_Proof,
+		PublicKey:        photo.Proof.PublicKey,
+		Cameras:          photo.Proof.Cameras,
+		Revocation_Epoch: photo.Proof.Revocation_Epoch,
+		Editors:          photo.Proof.Editors,
+		Input_Commitment: photo.Proof.Input_Commitment,
+		Commitment:       image.ImageCommitment(photo.Z.Img),
+	}
+	if last.Input_Commitment == nil {
+		// An original image is its own input
+		if len(photo.Proof.Previous) > 0 {
+			return false, fmt.Errorf("%w: an original image has no previous edits", ErrImageMismatch)
+		}
+		last.Input_Commitment = last.Commitment
+	}
+	if err := verifyStep(vk, photo.Z, last, revocation_root, len(photo.Proof.Previous) == 0); err != nil {
+		return false, err
+	}
+
+	// Every previous edit, whose output is the next edit's input. The last edit shows that the camera was not
+	// revoked since, earlier ones are checked against the revocation list of their epoch.
+	for i, step := range photo.Proof.Previous {
+		next := last.Input_Commitment
+		if i+1 < len(photo.Proof.Previous) {
+			next = photo.Proof.Previous[i+1].Input_Commitment
+		}
+		if !bytes.Equal(step.Commitment, next) {
+			return false, fmt.Errorf("%w: edit %d's output is not the next edit's input", ErrImageMismatch, i+1)
+		}
+
+		z := photo.Z
+		if !vk.Mode.Private() {
+			if step.Image == nil || !bytes.Equal(image.ImageCommitment(*step.Image), step.Commitment) ||
+				!bytes.Equal(step.Image.PxlBytes, image.BigInt_to_Fr_Bytes(*step.Image)) {
+				return false, fmt.Errorf("%w: edit %d's image does not match its commitment", ErrImageMismatch, i+1)
+			}
+			z.Img = *step.Image
+		}
+
+		revocations, err := vk.Revocations.At(step.Revocation_Epoch)
+		if err != nil {
+			return false, err
+		}
+		root, err := revocations.Root()
+		if err != nil {
+			return false, err
+		}
+		if err := verifyStep(vk, z, step, root, i == 0); err != nil {
+			return false, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+	}
+
+	return true, nil
+}
+
+// Verify the PCD proof of one edit, whose Z_out is z, against the given revocation root.
+// Only the public values are recreated, z's image is only needed with Mode_Public.
+func verifyStep(vk VerifierKeys, z image.Z, step Edit_Proof, revocation_root []byte, input_original bool) error {
+	if step.PCD_Proof == nil {
+		return ErrNoProof
+	}
+	if step.PublicKey == nil && !vk.Mode.Hidden_Editor() {
+		return ErrKeyMismatch
+	}
+	cameras_root, err := camerasRoot(vk, step.Cameras)
+	if err != nil {
+		return err
+	}
+
+	// A hidden editor's key is not needed, it is proven to be one of the Admin's editors
+	var eddsa_pk_out eddsa.PublicKey
+	if step.PublicKey != nil {
+		eddsa_pk_out.Assign(1, step.PublicKey.Bytes())
+	}
+
+	circuit := PhotoGnark{
+		Z_out:          z.ToFr(),
+		PublicKey_out:  eddsa_pk_out,
+		Commitment_in:  step.Input_Commitment,
+		Input_Original: 0,
+		Cameras_Root:   cameras_root,
+
+		Revocation_Root: revocation_root,
+	}
+	if input_original {
+		circuit.Input_Original = 1
+	}
+
+	var assignment frontend.Circuit = &circuit
+	if vk.Mode.Private() {
+		editors_root := frontend.Variable(0)
+		if vk.Mode.Hidden_Editor() {
+			if editors_root, err = editorsRoot(vk, step.Editors); err != nil {
+				return err
+			}
+		}
+		assignment = circuit.Private(step.Commitment, vk.Mode, editors_root, zeroPath())
+	}
+
+	// Recreate the public witness from the public values
+	public_witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		return Wrap(ErrWitness, err)
+	}
+
+	// Verify the proof with the recreated public witness and verifying key
+	if err := groth16.Verify(step.PCD_Proof, vk.VerifyingKey, public_witness); err != nil {
+		return Wrap(ErrInvalidProof, err)
+	}
+	return nil
+}
+
+// Returns the root of the first n authorised cameras of the verifier keys, the root the proof was made against
+func camerasRoot(vk VerifierKeys, n int) ([]byte, error) {
+	cameras, err := vk.Cameras.Prefix(n)
+	if err != nil {
+		return nil, err
+	}
+	return cameras.Root()
+}
+
+// Returns the root of the verifier keys' revocation list at the given epoch, the root the proof was made against.
+// Returns ErrStaleRevocation if the epoch is more than Revocation_Window epochs behind the latest.
+func revocationRoot(vk VerifierKeys, epoch uint64) ([]byte, error) {
+	if epoch < vk.Revocations.Epoch && vk.Revocations.Epoch-epoch > vk.Revocation_Window {
+		return nil, fmt.Errorf("%w: epoch %d, the latest is %d and at most %d epochs behind it are accepted", ErrStaleRevocation, epoch, vk.Revocations.Epoch, vk.Revocation_Window)
+	}
+	revocations, err := vk.Revocations.At(epoch)
+	if err != nil {
+		return nil, err
+	}
+	return revocations.Root()
+}
+
+// Returns the root of the first n authorised editors of the verifier keys, the root the proof was made against
+func editorsRoot(vk VerifierKeys, n int) ([]byte, error) {
+	if n < 0 || n > len(vk.Editors.Keys) {
+		return nil, fmt.Errorf("%w: no prefix of %d keys in a set of %d", ErrInvalidKeySet, n, len(vk.Editors.Keys))
+	}
+	return Key_Set{Keys: vk.Editors.Keys[:n]}.Root()
+}
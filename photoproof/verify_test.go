@@ -0,0 +1,60 @@
+package photoproof
+
+import (
+	"errors"
+	"testing"
+)
+
+// A bundle of a revoked camera is invalid
+func TestVerifyBundle_Revoked(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Public, DefaultPolicy(), testImage(t, "random"))
+	photo = testProven(t, photo)
+	bundle, err := photo.Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vk := photo.VerifyingKeys
+	if verdict := VerifyBundle(bundle, vk); !verdict.Valid {
+		t.Fatal(verdict.Err)
+	}
+
+	if err := vk.Revocations.Revoke(photo.Z.Original_PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	vk.Revocation_Window = 1
+	verdict := VerifyBundle(bundle, vk)
+	if verdict.Valid || !verdict.Revoked || !errors.Is(verdict.Err, ErrRevoked) {
+		t.Fatalf("bundle of a revoked camera: valid %t, revoked %t, error %v", verdict.Valid, verdict.Revoked, verdict.Err)
+	}
+}
+
+// A proof of a hidden camera made against a past revocation list does not show that the camera was not revoked since
+func TestVerifyBundle_StaleEpoch(t *testing.T) {
+	_, photo := testOriginal(t, Mode_Private|Mode_Hidden_Camera, DefaultPolicy(), testImage(t, "random"))
+	photo = testProven(t, photo)
+	bundle, err := photo.Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle = bundle.HideCamera()
+
+	vk := photo.VerifyingKeys
+	if verdict := VerifyBundle(bundle, vk); !verdict.Valid {
+		t.Fatal(verdict.Err)
+	}
+
+	// The camera is revoked after proving, which the hidden bundle cannot show
+	if err := vk.Revocations.Revoke(photo.Z.Original_PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if verdict := VerifyBundle(bundle, vk); verdict.Valid || !errors.Is(verdict.Err, ErrStaleRevocation) {
+		t.Fatalf("stale bundle: valid %t, error %v, expected %v", verdict.Valid, verdict.Err, ErrStaleRevocation)
+	}
+
+	// ... unless the verifier accepts proofs made against the previous epoch
+	vk.Revocation_Window = 1
+	if verdict := VerifyBundle(bundle, vk); !verdict.Valid {
+		t.Fatal(verdict.Err)
+	}
+}
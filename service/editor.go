@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+/*--------------------------------------------------Editing Service----------------------------------------------*/
+
+// Default number of jobs waiting for a worker
+const Default_Max_Queue = 64
+
+// Status of an edit job
+type Job_Status string
+
+const (
+	Job_Queued  Job_Status = "queued"  // Waiting for a worker, or interrupted by a restart
+	Job_Running Job_Status = "running" // Being proven
+	Job_Done    Job_Status = "done"    // Result holds the edited bundle
+	Job_Failed  Job_Status = "failed"  // Error says why
+)
+
+// An edit request and its outcome. Jobs are persisted as JSON, one file per job.
+type Edit_Job struct {
+	Id             string             `json:"id"`
+	Status         Job_Status         `json:"status"`
+	Transformation string             `json:"transformation"`
+	Params         json.RawMessage    `json:"params,omitempty"`
+	Bundle         photoproof.Bundle  `json:"bundle"`
+	Result         *photoproof.Bundle `json:"result,omitempty"`
+	Error          string             `json:"error,omitempty"`
+	Created        time.Time          `json:"created"`
+	Updated        time.Time          `json:"updated"`
+}
+
+/*
+An Editor applies transformations to uploaded bundles and proves them in the background,
+signing every output with the server's editor key:
+
+	POST /jobs       body: {"bundle": ..., "transformation": "identity", "params": {...}}, response (202): the queued job
+	GET  /jobs/{id}  response: the job, with its edited bundle once it is done
+	GET  /health     response: the number of queued and running jobs
+
+Uploaded bundles are verified first: one that does not verify is rejected with 422 and its verdict.
+At most Max_Queue jobs wait for a worker, further requests are rejected with 503.
+Job state is persisted in Dir, so jobs that were queued or running when the service stopped
+are proven again when it restarts.
+*/
+type Editor struct {
+	User      photoproof.User         // Editor key, signs every edited image
+	Prover    photoproof.ProverKeys   // Admin's prover keys
+	Verifier  photoproof.VerifierKeys // Admin's verifier keys, which uploaded bundles must refer to
+	Dir       string                  // Where job state is persisted
+	Workers   int                     // Number of jobs proven at once, runtime.NumCPU() if 0
+	Max_Queue int                     // Default_Max_Queue if 0
+	Max_Bytes int64                   // Largest accepted upload, Default_Max_Bytes if 0
+
+	mu    sync.Mutex
+	jobs  map[string]*Edit_Job
+	queue chan string
+}
+
+// Compile the circuit if needed, restore the persisted jobs and start the workers, which stop when ctx is done
+func (e *Editor) Start(ctx context.Context) error {
+	if e.Max_Queue <= 0 {
+		e.Max_Queue = Default_Max_Queue
+	}
+	if e.Workers <= 0 {
+		e.Workers = runtime.NumCPU()
+	}
+
+	// Every job is proven with the same circuit, compile it once
+	if e.Prover.Compliance_Predicate == nil {
+		predicate, err := photoproof.Step(ctx, photoproof.Phase_Compile, func() (constraint.ConstraintSystem, error) {
+			return photoproof.Compile(e.Prover.Mode, e.Prover.Policy)
+		})
+		if err != nil {
+			return err
+		}
+		e.Prover.Compliance_Predicate = predicate
+	}
+
+	// Jobs hold the submitted photographs, only the service's user may read them, as with the keystore
+	if err := os.MkdirAll(e.Dir, 0o700); err != nil {
+		return err
+	}
+	if err := os.Chmod(e.Dir, 0o700); err != nil {
+		return err
+	}
+	pending, err := e.restore()
+	if err != nil {
+		return err
+	}
+
+	e.queue = make(chan string, max(e.Max_Queue, len(pending)))
+	for _, id := range pending {
+		e.queue <- id
+	}
+	photoproof.Logger().Info("editing service started", "restored", len(e.jobs), "pending", len(pending))
+
+	for i := 0; i < e.Workers; i++ {
+		go e.work(ctx)
+	}
+
+	return nil
+}
+
+// Returns the handler of the editing endpoints. Start() must be called first.
+func (e *Editor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", e.submit)
+	mux.HandleFunc("GET /jobs/{id}", e.poll)
+	mux.HandleFunc("GET /health", e.health)
+	return mux
+}
+
+/*------------------------------------------------------Handlers-------------------------------------------------*/
+
+// Body of POST /jobs
+type edit_request struct {
+	Bundle         photoproof.Bundle `json:"bundle"`
+	Transformation string            `json:"transformation"`
+	Params         json.RawMessage   `json:"params,omitempty"`
+}
+
+func (e *Editor) submit(w http.ResponseWriter, r *http.Request) {
+	max_bytes := e.Max_Bytes
+	if max_bytes <= 0 {
+		max_bytes = Default_Max_Bytes
+	}
+
+	var request edit_request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, max_bytes)).Decode(&request); err != nil {
+		var too_large *http.MaxBytesError
+		if errors.As(err, &too_large) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		writeError(w, http.StatusBadRequest, photoproof.Wrap(photoproof.ErrDecode, err))
+		return
+	}
+
+	// Reject what can never succeed before queuing it
+	if _, err := photoproof.ParseParameters(request.Transformation, request.Params); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if e.Prover.Policy.Slot(request.Transformation) < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %q", photoproof.ErrNotPermitted, request.Transformation))
+		return
+	}
+	if _, err := request.Bundle.Photograph(e.Prover, e.Verifier); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Only bundles that verify are edited, the verdict says why the others are rejected
+	if verdict := photoproof.VerifyBundle(request.Bundle, e.Verifier); !verdict.Valid {
+		photoproof.Logger().Info("rejected edit of an invalid bundle", "verifying_key", verdict.VerifyingKey, "error", verdict.Error)
+		writeJSON(w, http.StatusUnprocessableEntity, verdict)
+		return
+	}
+
+	id, err := newJobId()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	now := time.Now().UTC()
+	job := &Edit_Job{
+		Id:             id,
+		Status:         Job_Queued,
+		Transformation: request.Transformation,
+		Params:         request.Params,
+		Bundle:         request.Bundle,
+		Created:        now,
+		Updated:        now,
+	}
+
+	e.mu.Lock()
+	if len(e.queue) == cap(e.queue) {
+		e.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, errors.New("queue is full"))
+		return
+	}
+	if err := e.persist(job); err != nil {
+		e.mu.Unlock()
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	e.jobs[id] = job
+	e.queue <- id // Never blocks, the queue is not full and only grows under e.mu
+	snapshot := *job
+	e.mu.Unlock()
+
+	photoproof.Logger().Info("queued edit job", "id", id, "transformation", job.Transformation)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (e *Editor) poll(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	job, ok := e.jobs[r.PathValue("id")]
+	var snapshot Edit_Job
+	if ok {
+		snapshot = *job
+	}
+	e.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("unknown job"))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (e *Editor) health(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	running := 0
+	for _, job := range e.jobs {
+		if job.Status == Job_Running {
+			running++
+		}
+	}
+	queued := len(e.queue)
+	e.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "queued": queued, "running": running, "max_queue": cap(e.queue)})
+}
+
+/*------------------------------------------------------Workers--------------------------------------------------*/
+
+func (e *Editor) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-e.queue:
+			e.run(ctx, id)
+		}
+	}
+}
+
+func (e *Editor) run(ctx context.Context, id string) {
+	job := e.update(id, func(job *Edit_Job) { job.Status = Job_Running })
+
+	result, err := e.edit(ctx, job)
+
+	// A job interrupted by shutdown is left queued, and resumed on restart
+	if ctx.Err() != nil {
+		e.update(id, func(job *Edit_Job) { job.Status = Job_Queued })
+		return
+	}
+
+	e.update(id, func(job *Edit_Job) {
+		if err != nil {
+			job.Status, job.Error = Job_Failed, err.Error()
+			photoproof.Logger().Warn("edit job failed", "id", id, "error", err)
+			return
+		}
+		job.Status, job.Result = Job_Done, &result
+		photoproof.Logger().Info("edit job done", "id", id)
+	})
+}
+
+func (e *Editor) edit(ctx context.Context, job Edit_Job) (photoproof.Bundle, error) {
+	reg, ok := photoproof.Lookup(job.Transformation)
+	if !ok {
+		return photoproof.Bundle{}, fmt.Errorf("%w: %q", photoproof.ErrUnknownTransformation, job.Transformation)
+	}
+	params, err := photoproof.ParseParameters(job.Transformation, job.Params)
+	if err != nil {
+		return photoproof.Bundle{}, err
+	}
+
+	photo, err := job.Bundle.Photograph(e.Prover, e.Verifier)
+	if err != nil {
+		return photoproof.Bundle{}, err
+	}
+
+	edited, err := e.User.Edit(ctx, photo, reg.Transformation, params)
+	if err != nil {
+		return photoproof.Bundle{}, err
+	}
+
+	return edited.Bundle()
+}
+
+/*----------------------------------------------------Job State--------------------------------------------------*/
+
+// Apply fn to the job, persist it and return a copy
+func (e *Editor) update(id string, fn func(job *Edit_Job)) Edit_Job {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	job := e.jobs[id]
+	fn(job)
+	job.Updated = time.Now().UTC()
+	if err := e.persist(job); err != nil {
+		photoproof.Logger().Warn("persisting edit job failed", "id", id, "error", err)
+	}
+
+	return *job
+}
+
+// Write the job's state, replacing the previous one atomically
+func (e *Editor) persist(job *Edit_Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	// A new temporary file each time, so it is always created with mode 0600
+	tmp, err := os.CreateTemp(e.Dir, job.Id+".json.tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(e.Dir, job.Id+".json"))
+}
+
+// Load every persisted job and return the ids of those that still need a worker, oldest first
+func (e *Editor) restore() ([]string, error) {
+	e.jobs = map[string]*Edit_Job{}
+
+	paths, err := filepath.Glob(filepath.Join(e.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []*Edit_Job{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		job := &Edit_Job{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if job.Status == Job_Queued || job.Status == Job_Running {
+			job.Status = Job_Queued
+			pending = append(pending, job)
+		}
+		e.jobs[job.Id] = job
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Created.Before(pending[j].Created) })
+	ids := make([]string, len(pending))
+	for i, job := range pending {
+		ids[i] = job.Id
+	}
+
+	return ids, nil
+}
+
+func newJobId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drakstik/PhotoGnark_ACDF/camera"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Uploaded bundles are verified before they are queued
+func TestEditor_Submit(t *testing.T) {
+	ctx := context.Background()
+	cam, err := camera.NewCamera(ctx, photoproof.Mode_Public, photoproof.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	photo, err := cam.TakePhotograph(ctx, "random")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := photo.Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	editor, err := photoproof.NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Workers stop at once, so submitted jobs stay queued
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	dir := t.TempDir()
+	e := &Editor{User: editor, Prover: cam.ProvingKey, Verifier: cam.VerifyingKey, Dir: dir, Workers: 1}
+	if err := e.Start(stopped); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(e.Handler())
+	defer server.Close()
+
+	submit := func(bundle photoproof.Bundle) *http.Response {
+		body, err := json.Marshal(edit_request{Bundle: bundle, Transformation: "identity"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	response := submit(bundle)
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("valid bundle: status %d, expected %d", response.StatusCode, http.StatusAccepted)
+	}
+
+	// Only the service's user can read the queued job
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range append(paths, dir) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := os.FileMode(0o600)
+		if info.IsDir() {
+			expected = 0o700
+		}
+		if mode := info.Mode().Perm(); mode != expected {
+			t.Fatalf("%s has mode %o, expected %o", path, mode, expected)
+		}
+	}
+	if len(paths) != 1 {
+		t.Fatalf("%d files in the job directory, expected 1", len(paths))
+	}
+
+	// Pixels that differ from the proven ones
+	tampered := bundle
+	tampered.Image.Pxls[0].RGB[0] ^= 0xff
+	tampered.Image.PxlBytes = image.BigInt_to_Fr_Bytes(tampered.Image)
+	response = submit(tampered)
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("tampered bundle: status %d, expected %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+	var verdict photoproof.Verdict
+	if err := json.NewDecoder(response.Body).Decode(&verdict); err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Valid || verdict.Error == "" {
+		t.Fatalf("tampered bundle: verdict %+v, expected an invalid verdict", verdict)
+	}
+}
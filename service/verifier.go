@@ -0,0 +1,102 @@
+// Package service exposes PhotoGnark over HTTP, for deployments that do not link the Go packages directly.
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+/*-----------------------------------------------Verification Service--------------------------------------------*/
+
+// Default limit on the size of an uploaded bundle. A bundle of an N*N image is a few kilobytes.
+const Default_Max_Bytes int64 = 1 << 20
+
+/*
+A Verifier serves verdicts on uploaded proof bundles, checked against the verifier keys it trusts:
+
+	POST /verify   body: a bundle (see photoproof.Bundle) or a PNG with an embedded bundle, response: a photoproof.Verdict
+	GET  /health   response: the fingerprints of the trusted verifying keys
+
+An invalid bundle is still a successful request (200), its verdict says why it is invalid.
+Only malformed (400) or oversized (413) uploads fail.
+*/
+type Verifier struct {
+	Trusted   []photoproof.VerifierKeys // Loaded at startup, e.g. with photoproof.ReadVerifierKeys()
+	Max_Bytes int64                     // Largest accepted upload, Default_Max_Bytes if 0
+}
+
+// Returns the handler of the verification endpoints
+func (v *Verifier) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /verify", v.verify)
+	mux.HandleFunc("GET /health", v.health)
+	return mux
+}
+
+func (v *Verifier) verify(w http.ResponseWriter, r *http.Request) {
+	max_bytes := v.Max_Bytes
+	if max_bytes <= 0 {
+		max_bytes = Default_Max_Bytes
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, max_bytes))
+	if err != nil {
+		var too_large *http.MaxBytesError
+		if errors.As(err, &too_large) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// A PNG is verified against its own pixels, with its embedded bundle
+	var bundle photoproof.Bundle
+	if photoproof.IsPNG(body) {
+		bundle, err = photoproof.ReadPNG(bytes.NewReader(body))
+	} else {
+		bundle, err = photoproof.ReadBundle(bytes.NewReader(body))
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	verdict := photoproof.VerifyBundle(bundle, v.Trusted...)
+	photoproof.Logger().Info("verified bundle", "valid", verdict.Valid, "verifying_key", verdict.VerifyingKey, "error", verdict.Error)
+
+	writeJSON(w, http.StatusOK, verdict)
+}
+
+func (v *Verifier) health(w http.ResponseWriter, r *http.Request) {
+	fingerprints := []string{}
+	for _, vk := range v.Trusted {
+		fingerprint, err := vk.Fingerprint()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "verifying_keys": fingerprints})
+}
+
+/*-----------------------------------------------------Responses-------------------------------------------------*/
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		photoproof.Logger().Warn("writing response failed", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
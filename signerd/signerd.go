@@ -0,0 +1,199 @@
+// Package signerd keeps a secret key in its own process, standing in for the HSM or secure element of a real
+// camera. The daemon serves signatures over a Unix socket and a Client, which is a photoproof.Message_Signer,
+// asks it to sign without ever seeing the key:
+//
+//	daemon:  signerd.Serve(ctx, listener, signer)
+//	camera:  client, err := signerd.Dial(socket)
+//	         admin := photoproof.NewUserWithSigner(client)
+//
+// The daemon only signs the images, capture hashes and custody entries it hashes itself, never a digest it is
+// handed, so that a process connecting to it cannot get any message of its choice signed by the camera.
+package signerd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdhash "hash"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/consensys/gnark-crypto/signature"
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+/*------------------------------------------------------Daemon---------------------------------------------------*/
+
+// RPC service registered by Serve(). Its methods are only meant to be called through a Client.
+type Service struct {
+	signer photoproof.Signer
+}
+
+// How far a capture time may be from the daemon's clock
+const Max_Capture_Skew = 5 * time.Minute
+
+// A message to sign, and the hash function to sign it with
+type Sign_Request struct {
+	Message photoproof.Signed_Message
+	Hash    image.Hash
+}
+
+// Returns the serialised public key
+func (s *Service) Public(_ struct{}, reply *[]byte) error {
+	*reply = s.signer.Public().Bytes()
+	return nil
+}
+
+// Returns the signature of the request's message, hashed with the request's hash function.
+// Capture hashes are only signed for capture times within Max_Capture_Skew of the daemon's clock.
+func (s *Service) Sign(request Sign_Request, reply *[]byte) error {
+	if capture := request.Message.Capture; capture != nil {
+		skew := time.Since(time.Unix(int64(capture.Time), 0)).Abs()
+		if skew > Max_Capture_Skew {
+			photoproof.Logger().Warn("refused to sign a capture", "time", capture.Time, "skew", skew)
+			return fmt.Errorf("signerd: capture time %d is %s away from the daemon's clock", capture.Time, skew)
+		}
+	}
+
+	digest, err := request.Message.Digest(request.Hash)
+	if err != nil {
+		photoproof.Logger().Warn("signing failed", "error", err)
+		return err
+	}
+	sig, err := s.signer.Sign(digest, request.Hash.New())
+	if err != nil {
+		photoproof.Logger().Warn("signing failed", "error", err)
+		return err
+	}
+
+	photoproof.Logger().Info("signed", "digest", fmt.Sprintf("%x", digest), "hash", request.Hash, "capture", request.Message.Capture != nil, "custody", request.Message.Custody != nil)
+	*reply = sig
+	return nil
+}
+
+// Serve signatures by signer on listener until ctx is cancelled. Messages are hashed with the hash function the
+// client asks for, one of image.Hash, as photoproof.User.Sign() does.
+func Serve(ctx context.Context, listener net.Listener, signer photoproof.Signer) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Signer", &Service{signer: signer}); err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go server.ServeConn(conn)
+	}
+}
+
+// Listen on a Unix socket at path that only the current user can connect to, replacing a stale socket.
+// The socket is left at path when the listener is closed, the caller removes it.
+func Listen(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// The socket is bound in a new directory that only the current user can enter, made 0600 there, then moved
+	// into place, so no one else can connect before its mode is set. The process' umask is left alone.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".signerd-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	bound := filepath.Join(dir, "socket")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: bound, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(bound, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(bound, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+/*------------------------------------------------------Client---------------------------------------------------*/
+
+// Returned by Client.Sign()
+var ErrDigest = errors.New("signerd: the daemon does not sign digests, only images & custody entries")
+
+// A photoproof.Message_Signer whose secret key is held by a signing daemon. It is safe for concurrent use.
+type Client struct {
+	rpc    *rpc.Client
+	public signature.PublicKey
+}
+
+// Connect to the signing daemon listening on the Unix socket at path
+func Dial(path string) (*Client, error) {
+	client, err := rpc.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	var public []byte
+	if err := client.Call("Signer.Public", struct{}{}, &public); err != nil {
+		client.Close()
+		return nil, err
+	}
+	key, err := photoproof.DecodePublicKey(public)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &Client{rpc: client, public: key}, nil
+}
+
+// Returns the daemon's public key
+func (c *Client) Public() signature.PublicKey {
+	return c.public
+}
+
+// Returns ErrDigest: the daemon does not sign digests, see SignMessage()
+func (c *Client) Sign(_ []byte, _ stdhash.Hash) ([]byte, error) {
+	return nil, ErrDigest
+}
+
+// Returns the daemon's signature of message's digest, hashed with h. The daemon hashes the message itself, and
+// the signature is checked against the digest the client expects.
+func (c *Client) SignMessage(h image.Hash, message photoproof.Signed_Message) ([]byte, error) {
+	digest, err := message.Digest(h)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig []byte
+	if err := c.rpc.Call("Signer.Sign", Sign_Request{Message: message, Hash: h}, &sig); err != nil {
+		return nil, err
+	}
+	if err := photoproof.VerifySignature(h, c.public, digest, sig); err != nil {
+		return nil, fmt.Errorf("signerd: the daemon signed another message: %w", err)
+	}
+	return sig, nil
+}
+
+// Close the connection to the daemon
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
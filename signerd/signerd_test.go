@@ -0,0 +1,142 @@
+package signerd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// Returns a client of a daemon serving a new key, stopped at the end of the test
+func testDaemon(t *testing.T) (*Client, string) {
+	t.Helper()
+	user, err := photoproof.NewUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "camera.sock")
+	listener, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, listener, user.Signer) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+
+	client, err := Dial(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, socket
+}
+
+func TestListen(t *testing.T) {
+	_, socket := testDaemon(t)
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Fatalf("socket mode %o, expected 600", mode)
+	}
+
+	// The directory the socket was bound in is gone
+	entries, err := os.ReadDir(filepath.Dir(socket))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d entries next to the socket, expected only the socket", len(entries)-1)
+	}
+}
+
+func TestClient_Sign(t *testing.T) {
+	client, _ := testDaemon(t)
+	admin := photoproof.NewUserWithSigner(client)
+
+	img, err := image.NewImage("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range []image.Hash{image.Hash_MiMC, image.Hash_Poseidon2} {
+		sig, err := admin.Sign(h, img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := photoproof.VerifySignature(h, admin.PublicKey, h.ImageHash(img), sig); err != nil {
+			t.Fatalf("%s image signature: %v", h, err)
+		}
+	}
+
+	capture := image.Capture{Time: uint64(time.Now().Unix()), Counter: 7}
+	sig, err := admin.SignCapture(image.Hash_MiMC, img, capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := photoproof.VerifySignature(image.Hash_MiMC, admin.PublicKey, image.CaptureHash(img, capture), sig); err != nil {
+		t.Fatalf("capture signature: %v", err)
+	}
+
+	entry := photoproof.Custody_Entry{Transformation: photoproof.Custody_Capture, Editor: admin.PublicKey.Bytes(), Timestamp: time.Now().UTC()}
+	sig, err = client.SignMessage(image.Hash_MiMC, photoproof.Signed_Message{Custody: &entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := entry.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := photoproof.VerifySignature(image.Hash_MiMC, admin.PublicKey, digest, sig); err != nil {
+		t.Fatalf("custody entry signature: %v", err)
+	}
+}
+
+func TestClient_NotAnOracle(t *testing.T) {
+	client, _ := testDaemon(t)
+	admin := photoproof.NewUserWithSigner(client)
+
+	img, err := image.NewImage("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A digest of the caller's choice
+	if _, err := admin.SignDigest(image.Hash_MiMC, image.Hash_MiMC.ImageHash(img)); !errors.Is(err, ErrDigest) {
+		t.Fatalf("signed a digest: %v", err)
+	}
+
+	// PxlBytes that are not the image's
+	forged := img
+	forged.PxlBytes = make([]byte, len(img.PxlBytes))
+	sig, err := admin.Sign(image.Hash_MiMC, forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := photoproof.VerifySignature(image.Hash_MiMC, admin.PublicKey, image.Hash_MiMC.ImageHash(forged), sig); err == nil {
+		t.Fatal("signed PxlBytes that are not the image's")
+	}
+
+	// A backdated capture
+	capture := image.Capture{Time: uint64(time.Now().Add(-2 * Max_Capture_Skew).Unix())}
+	if _, err := admin.SignCapture(image.Hash_MiMC, img, capture); err == nil {
+		t.Fatal("signed a backdated capture")
+	}
+
+	// No message
+	if _, err := client.SignMessage(image.Hash_MiMC, photoproof.Signed_Message{}); err == nil {
+		t.Fatal("signed an empty message")
+	}
+}
@@ -0,0 +1,57 @@
+// Package tsa is a local stand-in for an RFC 3161 timestamp authority, for cameras whose own clock is not trusted.
+//
+// The authority is sent the capture digest of an image (image.CaptureDigest), sets the capture time from its own
+// clock and signs the resulting original hash with a serial number. Photographs carry the token, which
+// photoproof.VerifyTimestamp checks. Unlike RFC 3161, the token is signed with EdDSA over BN254 and MiMC, like
+// everything else in PhotoGnark, and is not ASN.1 encoded.
+package tsa
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/drakstik/PhotoGnark_ACDF/image"
+	"github.com/drakstik/PhotoGnark_ACDF/photoproof"
+)
+
+// A timestamp authority, it implements camera.Timestamper
+type Authority struct {
+	User  photoproof.User  // Signs the tokens
+	Clock func() time.Time // Sets the capture time, time.Now if nil
+}
+
+// Returns an authority signing with user's key and setting the time from the local clock
+func New(user photoproof.User) *Authority {
+	return &Authority{User: user}
+}
+
+// Returns the token of a capture digest, for the current time of the authority's clock.
+// Serial numbers are random, so that authorities sharing a key never issue the same one.
+func (authority *Authority) Timestamp(capture_digest []byte) (image.Timestamp_Token, error) {
+	now := time.Now
+	if authority.Clock != nil {
+		now = authority.Clock
+	}
+	t := uint64(now().Unix())
+
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return image.Timestamp_Token{}, err
+	}
+	token := image.Timestamp_Token{
+		Time:      t,
+		Serial:    binary.BigEndian.Uint64(serial[:]),
+		Authority: authority.User.PublicKey.Bytes(),
+	}
+
+	original_hash := image.OriginalHash(capture_digest, t)
+	sig, err := authority.User.SignDigest(image.Hash_MiMC, image.TimestampDigest(original_hash, token.Serial))
+	if err != nil {
+		return image.Timestamp_Token{}, err
+	}
+	token.Signature = sig
+
+	photoproof.Logger().Debug("timestamped capture digest", "time", t, "serial", token.Serial)
+	return token, nil
+}